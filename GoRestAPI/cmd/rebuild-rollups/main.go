@@ -0,0 +1,32 @@
+// Command rebuild-rollups recomputes the monthly report rollups from the
+// transactions table, for one user or for everyone.
+//
+//	go run ./cmd/rebuild-rollups [-user ID]
+package main
+
+import (
+	"flag"
+	"log"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/rollup"
+)
+
+func main() {
+	userID := flag.Uint("user", 0, "only rebuild the rollups of this user ID")
+	flag.Parse()
+
+	config.LoadConfig()
+	config.RunMigrations(config.AppConfig.DBPath)
+	config.InitDB()
+
+	if err := rollup.Rebuild(config.DB, *userID); err != nil {
+		log.Fatalf("failed to rebuild rollups: %v", err)
+	}
+	var rows int64
+	query := config.DB.Table("monthly_rollups")
+	if *userID != 0 {
+		query = query.Where("user_id = ?", *userID)
+	}
+	query.Count(&rows)
+	log.Printf("rebuilt %d rollup rows", rows)
+}
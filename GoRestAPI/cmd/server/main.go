@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"time"
 	"github.com/gin-gonic/gin"
 	"expense-tracker/internal/config"
 	"expense-tracker/internal/handlers"
+	"expense-tracker/internal/jobs"
 	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/notify"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	swaggerFiles "github.com/swaggo/files"
 	_ "expense-tracker/docs"
@@ -22,6 +26,15 @@ func main() {
 	// Seed initial users for login testing
 	config.SeedUsers()
 	config.SeedDemoData()
+	config.PromoteAdmins()
+	notify.SetMailer(notify.NewMailer(config.AppConfig.Mail))
+	jobs.Start(context.Background(),
+		jobs.Job{Name: "net-worth-snapshots", Interval: time.Hour, Run: handlers.RecordNetWorthSnapshots},
+		jobs.Job{Name: "anomaly-alerts", Interval: 6 * time.Hour, Run: handlers.PushAnomalyNotifications},
+		jobs.Job{Name: "digests", Interval: time.Minute, Run: handlers.SendDueDigests},
+		jobs.Job{Name: "idempotency-keys", Interval: time.Hour, Run: middleware.PurgeIdempotencyKeys},
+		jobs.Job{Name: "trash-purge", Interval: time.Hour, Run: handlers.PurgeTrash},
+	)
 	r := gin.Default()
 	r.Use(middleware.CORSMiddleware())
 
@@ -33,28 +46,133 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Retried POST requests with the same Idempotency-Key replay the first response.
+	// It only applies to authenticated requests, so credentials and tokens sent to
+	// the auth routes are never stored.
+	idempotency := middleware.IdempotencyMiddleware()
+
 	// Auth routes
 	r.POST("/auth/register", handlers.Register)
 	r.POST("/auth/login", handlers.Login)
 
 	// Protected routes
 	authMiddleware := middleware.JWTAuthMiddleware()
-	api := r.Group("", authMiddleware)
+	api := r.Group("", authMiddleware, idempotency)
+
+	// Current user endpoints
+	api.GET("/me", handlers.GetCurrentUser)
+	api.PUT("/me", handlers.UpdateCurrentUser)
 
 	// Transaction endpoints
 	api.GET("/transactions", handlers.ListTransactions)
 	api.POST("/transactions", handlers.CreateTransaction)
+	api.POST("/transactions/bulk", handlers.BulkTransactions)
 	api.GET("/transactions/:id", handlers.GetTransaction)
 	api.PUT("/transactions/:id", handlers.UpdateTransaction)
+	api.PATCH("/transactions/:id", handlers.PatchTransaction)
 	api.DELETE("/transactions/:id", handlers.DeleteTransaction)
+	api.POST("/transactions/:id/restore", handlers.RestoreTransaction)
+	api.GET("/transactions/:id/history", handlers.GetTransactionHistory)
+	api.POST("/transactions/:id/revert", handlers.RevertTransaction)
+
+	// Saved view endpoints
+	api.GET("/views", handlers.ListViews)
+	api.POST("/views", handlers.CreateView)
+	api.PUT("/views/:id", handlers.UpdateView)
+	api.DELETE("/views/:id", handlers.DeleteView)
 
 	// Category endpoints
 	api.GET("/categories", handlers.ListCategories)
 	api.POST("/categories", handlers.CreateCategory)
+	api.GET("/categories/:id", handlers.GetCategory)
 	api.PUT("/categories/:id", handlers.UpdateCategory)
+	api.PATCH("/categories/:id", handlers.PatchCategory)
 	api.DELETE("/categories/:id", handlers.DeleteCategory)
+	api.POST("/categories/:id/restore", handlers.RestoreCategory)
+
+	// Trash endpoints
+	api.GET("/trash", handlers.ListTrash)
+
+	// Category template endpoints
+	api.GET("/category-templates", handlers.ListCategoryTemplates)
+	api.POST("/category-templates/:key/apply", handlers.ApplyCategoryTemplate)
+
+	// Account endpoints
+	api.GET("/accounts", handlers.ListAccounts)
+	api.POST("/accounts", handlers.CreateAccount)
+	api.PUT("/accounts/:id", handlers.UpdateAccount)
+	api.DELETE("/accounts/:id", handlers.DeleteAccount)
+
+	// Budget endpoints
+	api.GET("/budgets", handlers.ListBudgets)
+	api.POST("/budgets", handlers.CreateBudget)
+	api.GET("/budgets/:id", handlers.GetBudget)
+	api.PUT("/budgets/:id", handlers.UpdateBudget)
+	api.DELETE("/budgets/:id", handlers.DeleteBudget)
+
+	// Savings goal endpoints
+	api.GET("/goals", handlers.ListGoals)
+	api.POST("/goals", handlers.CreateGoal)
+	api.GET("/goals/:id", handlers.GetGoal)
+	api.PUT("/goals/:id", handlers.UpdateGoal)
+	api.DELETE("/goals/:id", handlers.DeleteGoal)
+
+	// Asset and liability endpoints
+	api.GET("/assets", handlers.ListAssets)
+	api.POST("/assets", handlers.CreateAsset)
+	api.PUT("/assets/:id", handlers.UpdateAsset)
+	api.DELETE("/assets/:id", handlers.DeleteAsset)
+	api.GET("/assets/:id/valuations", handlers.ListValuations)
+	api.POST("/assets/:id/valuations", handlers.SetValuation)
+	api.DELETE("/assets/:id/valuations/:valuation_id", handlers.DeleteValuation)
+
+	// Tag endpoints
+	api.GET("/tags", handlers.ListTags)
+	api.DELETE("/tags/:id", handlers.DeleteTag)
+
+	// Recurring transaction endpoints
+	api.GET("/recurring", handlers.ListRecurring)
+	api.POST("/recurring", handlers.CreateRecurring)
+	api.PUT("/recurring/:id", handlers.UpdateRecurring)
+	api.DELETE("/recurring/:id", handlers.DeleteRecurring)
+	api.POST("/subscriptions/convert", handlers.ConvertSubscription)
+
+	// Envelope budgeting endpoints
+	api.PUT("/envelopes/:month/:category_id", handlers.AssignEnvelope)
+	api.POST("/envelopes/:month/move", handlers.MoveEnvelopeMoney)
+
+	// Notification endpoints
+	api.GET("/notifications", handlers.ListNotifications)
+	api.POST("/notifications/read-all", handlers.MarkAllNotificationsRead)
+	api.POST("/notifications/:id/read", handlers.MarkNotificationRead)
+	api.GET("/webhooks", handlers.ListWebhooks)
+	api.POST("/webhooks", handlers.CreateWebhook)
+	api.DELETE("/webhooks/:id", handlers.DeleteWebhook)
 
 	api.GET("/reports/summary", handlers.GetSummary)
+	api.GET("/reports/budget", handlers.GetBudgetReport)
+	api.GET("/reports/envelopes", handlers.GetEnvelopeReport)
+	api.GET("/reports/compare", handlers.GetComparisonReport)
+	api.GET("/reports/forecast", handlers.GetForecast)
+	api.GET("/reports/net-worth", handlers.GetNetWorthReport)
+	api.GET("/reports/anomalies", handlers.GetAnomalyReport)
+	api.GET("/reports/subscriptions", handlers.GetSubscriptionReport)
+	api.GET("/reports/statement", handlers.GetStatement)
+	api.GET("/reports/pivot", handlers.GetPivotReport)
+	api.GET("/charts/categories", handlers.GetCategoryChart)
+	api.GET("/charts/income-expense", handlers.GetIncomeExpenseChart)
+	api.GET("/charts/balances", handlers.GetBalanceChart)
+	api.GET("/digests", handlers.ListDigests)
+	api.POST("/digests", handlers.CreateDigest)
+	api.PUT("/digests/:id", handlers.UpdateDigest)
+	api.DELETE("/digests/:id", handlers.DeleteDigest)
+	api.POST("/digests/:id/send", handlers.SendDigestNow)
+
+	// Admin endpoints
+	admin := api.Group("/admin", middleware.AdminOnlyMiddleware())
+	admin.GET("/category-templates/:key", handlers.ExportCategoryTemplate)
+	admin.POST("/category-templates", handlers.ImportCategoryTemplate)
+	admin.GET("/audit", handlers.ListAuditLog)
 
 	r.Run()
 }
@@ -0,0 +1,116 @@
+// Package audit appends entries to the audit log. Every write to a
+// transaction, category or user records who made it, from which IP, and JSON
+// snapshots of the entity before and after together with the changed fields.
+// Entries are written in the same database transaction as the change they
+// describe, so a change is never committed without its entry.
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"expense-tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+// Entity types
+const (
+	EntityTransaction = "transaction"
+	EntityCategory    = "category"
+	EntityUser        = "user"
+)
+
+// Actions
+const (
+	ActionCreate      = "create"
+	ActionUpdate      = "update"
+	ActionDelete      = "delete"
+	ActionRestore     = "restore"
+	ActionRevert      = "revert"
+	ActionPurge       = "purge"
+	ActionRegister    = "register"
+	ActionLogin       = "login"
+	ActionLoginFailed = "login_failed"
+)
+
+// Actor is who made a change and from where. UserID is 0 for anonymous
+// requests and background jobs.
+type Actor struct {
+	UserID uint
+	IP     string
+}
+
+// Change is the old and new value of a field
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// volatile fields change with every write and are left out of snapshots
+var volatile = []string{"version", "snippet"}
+
+// Snapshot turns an entity into the JSON object stored in the log. nil stays nil.
+func Snapshot(v interface{}) (map[string]interface{}, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, k := range volatile {
+		delete(doc, k)
+	}
+	return doc, nil
+}
+
+// Diff returns the fields whose values differ between two snapshots. A field
+// missing on one side counts as null.
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+	for k, v := range after {
+		if !reflect.DeepEqual(before[k], v) {
+			changes[k] = Change{before[k], v}
+		}
+	}
+	for k, v := range before {
+		if _, ok := after[k]; !ok && v != nil {
+			changes[k] = Change{v, nil}
+		}
+	}
+	return changes
+}
+
+// Record appends an entry for a change to an entity. before and after are the
+// entity before and after the change, nil for a create or a delete; version
+// is the entity version after the change, 0 for unversioned entities.
+func Record(db *gorm.DB, actor Actor, action, entityType string, entityID, version uint, before, after interface{}) error {
+	b, err := Snapshot(before)
+	if err != nil {
+		return err
+	}
+	a, err := Snapshot(after)
+	if err != nil {
+		return err
+	}
+	entry := models.AuditEntry{
+		ActorID:    actor.UserID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Version:    version,
+		IP:         actor.IP,
+	}
+	if b != nil {
+		entry.Before, _ = json.Marshal(b)
+	}
+	if a != nil {
+		entry.After, _ = json.Marshal(a)
+	}
+	entry.Changes, _ = json.Marshal(Diff(b, a))
+	return db.Create(&entry).Error
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	tx := &models.Transaction{ID: 2, Amount: -9, Date: time.Date(2025, 7, 5, 0, 0, 0, 0, time.UTC), Version: 3, Tags: []string{"dinner"}, Snippet: "x"}
+	doc, err := Snapshot(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, -9.0, doc["amount"])
+	assert.Equal(t, "2025-07-05T00:00:00Z", doc["date"])
+	assert.Equal(t, []interface{}{"dinner"}, doc["tags"])
+	assert.NotContains(t, doc, "version")
+	assert.NotContains(t, doc, "snippet")
+
+	var none *models.Transaction
+	doc, err = Snapshot(none)
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
+	doc, err = Snapshot(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
+}
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{"amount": -9.0, "payee": "Pizza", "tags": []interface{}{"dinner"}, "account_id": 2.0}
+	after := map[string]interface{}{"amount": -12.0, "payee": "Pizza", "tags": []interface{}{"dinner", "friends"}}
+	assert.Equal(t, map[string]Change{
+		"amount":     {-9.0, -12.0},
+		"tags":       {[]interface{}{"dinner"}, []interface{}{"dinner", "friends"}},
+		"account_id": {2.0, nil},
+	}, Diff(before, after))
+
+	assert.Empty(t, Diff(after, after))
+	assert.Equal(t, map[string]Change{"payee": {nil, "Pizza"}}, Diff(nil, map[string]interface{}{"payee": "Pizza"}))
+	assert.Equal(t, map[string]Change{"payee": {"Pizza", nil}}, Diff(map[string]interface{}{"payee": "Pizza"}, nil))
+}
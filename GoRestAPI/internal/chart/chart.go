@@ -0,0 +1,348 @@
+// Package chart draws pie, bar and line charts as SVG or PNG using only the
+// standard library. A chart is laid out once against a small drawing surface
+// that is implemented by both an SVG writer and a rasterizer.
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Chart is a pie, bar or line chart
+type Chart interface {
+	draw(s surface, width, height float64)
+}
+
+// Slice is one category of a pie chart
+type Slice struct {
+	Label string
+	Value float64
+}
+
+// Pie shows the share of each slice in the total. Slices are drawn largest
+// first; negative values count by their magnitude and everything beyond
+// MaxPieSlices is grouped into "Other".
+type Pie struct {
+	Title  string
+	Slices []Slice
+}
+
+// Series is a named list of values, one per label
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// Bar draws the series side by side for each label
+type Bar struct {
+	Title  string
+	Labels []string
+	Series []Series
+}
+
+// Line draws each series as a line over the labels
+type Line struct {
+	Title  string
+	Labels []string
+	Series []Series
+}
+
+// MaxPieSlices is the number of slices shown before the rest is grouped
+const MaxPieSlices = 8
+
+// Palette is the series and slice colors, in order
+var Palette = []color.RGBA{
+	{0x4e, 0x79, 0xa7, 0xff}, {0xf2, 0x8e, 0x2b, 0xff}, {0xe1, 0x57, 0x59, 0xff}, {0x76, 0xb7, 0xb2, 0xff},
+	{0x59, 0xa1, 0x4f, 0xff}, {0xed, 0xc9, 0x48, 0xff}, {0xb0, 0x7a, 0xa1, 0xff}, {0xff, 0x9d, 0xa7, 0xff},
+	{0x9c, 0x75, 0x5f, 0xff}, {0xba, 0xb0, 0xac, 0xff},
+}
+
+var (
+	white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ink   = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	muted = color.RGBA{0x77, 0x77, 0x77, 0xff}
+	grid  = color.RGBA{0xe3, 0xe3, 0xe3, 0xff}
+)
+
+// Text anchors
+const (
+	anchorStart = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type point struct{ X, Y float64 }
+
+// surface is what charts draw on. The origin is the top-left corner, text is
+// positioned by its baseline and angles run clockwise from twelve o'clock.
+type surface interface {
+	rect(x, y, w, h float64, c color.RGBA)
+	polyline(points []point, width float64, c color.RGBA)
+	wedge(cx, cy, r, from, to float64, c color.RGBA)
+	text(x, y, size float64, anchor int, bold bool, c color.RGBA, s string)
+}
+
+// textWidth estimates the width of s; both renderers advance 0.6em per character
+func textWidth(s string, size float64) float64 {
+	return 0.6 * size * float64(len([]rune(s)))
+}
+
+// truncate shortens s with an ellipsis so that it fits in width
+func truncate(s string, width, size float64) string {
+	runes := []rune(s)
+	if textWidth(s, size) <= width {
+		return s
+	}
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// FormatValue formats an axis or legend value compactly, e.g. 1.5k or 12M
+func FormatValue(v float64) string {
+	a := math.Abs(v)
+	if a < 1e-9 {
+		return "0"
+	}
+	format := func(x float64, suffix string) string {
+		s := strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.1f", x), "0"), ".")
+		return s + suffix
+	}
+	switch {
+	case a >= 1e6:
+		return format(v/1e6, "M")
+	case a >= 1e3:
+		return format(v/1e3, "k")
+	case a == math.Trunc(a):
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return fmt.Sprintf("%.2f", v)
+	}
+}
+
+// niceScale returns an axis range covering [lo, hi] (and zero) divided into at
+// most ticks steps of 1, 2 or 5 times a power of ten
+func niceScale(lo, hi float64, ticks int) (float64, float64, float64) {
+	lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	if hi == lo {
+		hi = lo + 1
+	}
+	raw := (hi - lo) / float64(ticks)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude * 10
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*magnitude >= raw {
+			step = m * magnitude
+			break
+		}
+	}
+	return math.Floor(lo/step) * step, math.Ceil(hi/step) * step, step
+}
+
+const (
+	padding   = 16.0
+	titleSize = 16.0
+	labelSize = 11.0
+)
+
+// frame draws the background and title and returns the top of the plot area
+func frame(s surface, width, height float64, title string) float64 {
+	s.rect(0, 0, width, height, white)
+	if title == "" {
+		return padding
+	}
+	s.text(padding, padding+titleSize, titleSize, anchorStart, true, ink, truncate(title, width-2*padding, titleSize))
+	return padding + titleSize + 12
+}
+
+// legend draws a row of color swatches and series names and returns its height
+func legend(s surface, x, y float64, series []Series) float64 {
+	for i, ser := range series {
+		c := Palette[i%len(Palette)]
+		s.rect(x, y, 10, 10, c)
+		s.text(x+14, y+9, labelSize, anchorStart, false, ink, ser.Name)
+		x += 14 + textWidth(ser.Name, labelSize) + 16
+	}
+	return 20
+}
+
+func (p Pie) draw(s surface, width, height float64) {
+	top := frame(s, width, height, p.Title)
+	slices := make([]Slice, 0, len(p.Slices))
+	var total float64
+	for _, sl := range p.Slices {
+		if v := math.Abs(sl.Value); v > 0 {
+			slices = append(slices, Slice{sl.Label, v})
+			total += v
+		}
+	}
+	if total == 0 {
+		s.text(width/2, (top+height)/2, labelSize, anchorMiddle, false, muted, "No data")
+		return
+	}
+	sort.SliceStable(slices, func(i, j int) bool { return slices[i].Value > slices[j].Value })
+	if len(slices) > MaxPieSlices {
+		other := Slice{Label: "Other"}
+		for _, sl := range slices[MaxPieSlices-1:] {
+			other.Value += sl.Value
+		}
+		slices = append(slices[:MaxPieSlices-1], other)
+	}
+
+	r := math.Max(math.Min(height-top-padding, width/2-2*padding)/2, 10)
+	cx, cy := padding+r, top+(height-top-padding)/2
+	angle := 0.0
+	for i, sl := range slices {
+		sweep := 2 * math.Pi * sl.Value / total
+		s.wedge(cx, cy, r, angle, angle+sweep, Palette[i%len(Palette)])
+		angle += sweep
+	}
+
+	x := cx + r + 2*padding
+	y := cy - float64(len(slices))*20/2
+	for i, sl := range slices {
+		s.rect(x, y, 10, 10, Palette[i%len(Palette)])
+		label := fmt.Sprintf("%s  %s (%.0f%%)", sl.Label, FormatValue(sl.Value), 100*sl.Value/total)
+		s.text(x+14, y+9, labelSize, anchorStart, false, ink, truncate(label, width-x-14-padding, labelSize))
+		y += 20
+	}
+}
+
+// axes maps values to the plot area of a bar or line chart
+type axes struct {
+	left, top, right, bottom float64
+	lo, hi, step             float64
+}
+
+// newAxes fits the plot area around the y-axis labels and draws the grid
+func newAxes(s surface, width, height, top float64, series []Series) axes {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, ser := range series {
+		for _, v := range ser.Values {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		lo, hi = 0, 1
+	}
+	a := axes{top: top, right: width - padding, bottom: height - padding - labelSize - 6}
+	// leave some headroom above the highest value
+	if hi > 0 {
+		hi += 0.05 * (hi - math.Min(lo, 0))
+	}
+	a.lo, a.hi, a.step = niceScale(lo, hi, 5)
+	ticks := int(math.Round((a.hi-a.lo)/a.step)) + 1
+	tick := func(i int) float64 { return a.lo + float64(i)*a.step }
+	labelWidth := 0.0
+	for i := 0; i < ticks; i++ {
+		labelWidth = math.Max(labelWidth, textWidth(FormatValue(tick(i)), labelSize))
+	}
+	a.left = padding + labelWidth + 8
+	for i := 0; i < ticks; i++ {
+		v := tick(i)
+		y := a.y(v)
+		c := grid
+		if math.Abs(v) < a.step/2 {
+			c = muted
+		}
+		s.polyline([]point{{a.left, y}, {a.right, y}}, 1, c)
+		s.text(a.left-8, y+labelSize/3, labelSize, anchorEnd, false, muted, FormatValue(v))
+	}
+	return a
+}
+
+func (a axes) y(v float64) float64 {
+	return a.bottom - (v-a.lo)/(a.hi-a.lo)*(a.bottom-a.top)
+}
+
+// xLabels draws the labels centered at the given positions, skipping labels
+// evenly so that they do not overlap
+func (a axes) xLabels(s surface, labels []string, x func(i int) float64, slot float64) {
+	widest := 0.0
+	for _, l := range labels {
+		widest = math.Max(widest, textWidth(l, labelSize))
+	}
+	every := 1
+	if slot > 0 {
+		every = int(math.Ceil((widest + 8) / slot))
+	}
+	if every < 1 {
+		every = 1
+	}
+	for i := 0; i < len(labels); i += every {
+		s.text(x(i), a.bottom+labelSize+6, labelSize, anchorMiddle, false, muted, labels[i])
+	}
+}
+
+func (b Bar) draw(s surface, width, height float64) {
+	top := frame(s, width, height, b.Title)
+	top += legend(s, padding, top, b.Series) + 4
+	a := newAxes(s, width, height, top, b.Series)
+	if len(b.Labels) == 0 {
+		return
+	}
+	group := (a.right - a.left) / float64(len(b.Labels))
+	bar := group * 0.7 / float64(max(len(b.Series), 1))
+	zero := a.y(math.Max(a.lo, math.Min(0, a.hi)))
+	for i := range b.Labels {
+		x := a.left + float64(i)*group + group*0.15
+		for j, ser := range b.Series {
+			if i < len(ser.Values) {
+				y := a.y(ser.Values[i])
+				s.rect(x+float64(j)*bar, math.Min(y, zero), math.Max(bar-1, 1), math.Abs(zero-y), Palette[j%len(Palette)])
+			}
+		}
+	}
+	a.xLabels(s, b.Labels, func(i int) float64 { return a.left + (float64(i)+0.5)*group }, group)
+}
+
+func (l Line) draw(s surface, width, height float64) {
+	top := frame(s, width, height, l.Title)
+	top += legend(s, padding, top, l.Series) + 4
+	a := newAxes(s, width, height, top, l.Series)
+	if len(l.Labels) == 0 {
+		return
+	}
+	step := 0.0
+	if len(l.Labels) > 1 {
+		step = (a.right - a.left) / float64(len(l.Labels)-1)
+	}
+	x := func(i int) float64 {
+		if step == 0 {
+			return (a.left + a.right) / 2
+		}
+		return a.left + float64(i)*step
+	}
+	for j, ser := range l.Series {
+		points := make([]point, 0, len(ser.Values))
+		for i, v := range ser.Values {
+			points = append(points, point{x(i), a.y(v)})
+		}
+		if len(points) == 1 {
+			s.wedge(points[0].X, points[0].Y, 3, 0, 2*math.Pi, Palette[j%len(Palette)])
+		}
+		s.polyline(points, 2, Palette[j%len(Palette)])
+	}
+	a.xLabels(s, l.Labels, x, step)
+}
+
+// SVG writes the chart as an SVG image of the given size
+func SVG(w io.Writer, ch Chart, width, height int) error {
+	s := &svgSurface{}
+	ch.draw(s, float64(width), float64(height))
+	_, err := fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"Helvetica, Arial, sans-serif\">\n%s</svg>\n",
+		width, height, width, height, s.buf.String())
+	return err
+}
+
+// PNG writes the chart as a PNG image of the given size
+func PNG(w io.Writer, ch Chart, width, height int) error {
+	r := newRaster(width, height)
+	ch.draw(r, float64(width), float64(height))
+	return r.encode(w)
+}
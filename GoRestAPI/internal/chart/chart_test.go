@@ -0,0 +1,80 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNiceScale(t *testing.T) {
+	lo, hi, step := niceScale(120, 870, 5)
+	assert.Equal(t, []float64{0, 1000, 200}, []float64{lo, hi, step})
+	lo, hi, step = niceScale(-340, 1200, 5)
+	assert.Equal(t, []float64{-500, 1500, 500}, []float64{lo, hi, step})
+	lo, hi, _ = niceScale(0, 0, 5)
+	assert.True(t, hi > lo)
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "0", FormatValue(0))
+	assert.Equal(t, "250", FormatValue(250))
+	assert.Equal(t, "12.50", FormatValue(12.5))
+	assert.Equal(t, "1.5k", FormatValue(1500))
+	assert.Equal(t, "-2k", FormatValue(-2000))
+	assert.Equal(t, "3.2M", FormatValue(3_200_000))
+}
+
+func TestPieSVG(t *testing.T) {
+	pie := Pie{Title: "Expenses <July>", Slices: []Slice{{"Rent", -900}, {"Food", -300}, {"Zero", 0}}}
+	var buf bytes.Buffer
+	assert.NoError(t, SVG(&buf, pie, 400, 240))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "<svg "))
+	assert.Equal(t, 2, strings.Count(out, "<path "))
+	assert.Contains(t, out, "Expenses &lt;July&gt;")
+	assert.Contains(t, out, "Rent  900 (75%)")
+	assert.NotContains(t, out, "Zero")
+
+	// a single slice is a full circle
+	buf.Reset()
+	assert.NoError(t, SVG(&buf, Pie{Slices: []Slice{{"All", 5}}}, 400, 240))
+	assert.Contains(t, buf.String(), "<circle ")
+}
+
+func TestPieGroupsSmallSlices(t *testing.T) {
+	var slices []Slice
+	for i := 0; i < 12; i++ {
+		slices = append(slices, Slice{string(rune('A' + i)), float64(100 - i)})
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, SVG(&buf, Pie{Slices: slices}, 500, 300))
+	assert.Equal(t, MaxPieSlices, strings.Count(buf.String(), "<path "))
+	assert.Contains(t, buf.String(), "Other")
+}
+
+func TestBarAndLinePNG(t *testing.T) {
+	bar := Bar{Title: "Income vs expense", Labels: []string{"Jan", "Feb", "Mar"}, Series: []Series{
+		{"Income", []float64{3000, 3100, 2900}}, {"Expenses", []float64{2100, 2500, 1800}},
+	}}
+	line := Line{Title: "Balances", Labels: []string{"1", "2", "3", "4"}, Series: []Series{{"Checking", []float64{100, -50, 300, 250}}}}
+	for _, ch := range []Chart{bar, line, Pie{Slices: []Slice{{"A", 1}, {"B", 2}}}} {
+		var buf bytes.Buffer
+		assert.NoError(t, PNG(&buf, ch, 320, 200))
+		img, err := png.Decode(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, 320, img.Bounds().Dx())
+		assert.Equal(t, 200, img.Bounds().Dy())
+		// the first palette color is drawn somewhere
+		found := false
+		for y := 0; y < 200 && !found; y++ {
+			for x := 0; x < 320 && !found; x++ {
+				r, g, b, _ := img.At(x, y).RGBA()
+				found = r>>8 == 0x4e && g>>8 == 0x79 && b>>8 == 0xa7
+			}
+		}
+		assert.True(t, found)
+	}
+}
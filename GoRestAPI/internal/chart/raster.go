@@ -0,0 +1,154 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// supersample is the number of pixels drawn per output pixel along each axis;
+// averaging them smooths the edges of shapes and text
+const supersample = 3
+
+// raster draws onto an image
+type raster struct {
+	img           *image.RGBA
+	width, height int
+}
+
+func newRaster(width, height int) *raster {
+	return &raster{img: image.NewRGBA(image.Rect(0, 0, width*supersample, height*supersample)), width: width, height: height}
+}
+
+func (r *raster) rect(x, y, w, h float64, c color.RGBA) {
+	x0, y0 := int(math.Round(x*supersample)), int(math.Round(y*supersample))
+	x1, y1 := int(math.Round((x+w)*supersample)), int(math.Round((y+h)*supersample))
+	for py := max(y0, 0); py < min(y1, r.img.Rect.Dy()); py++ {
+		for px := max(x0, 0); px < min(x1, r.img.Rect.Dx()); px++ {
+			r.img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// fill fills a polygon with the even-odd rule, sampling pixel centers
+func (r *raster) fill(points []point, c color.RGBA) {
+	if len(points) < 3 {
+		return
+	}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	var xs []float64
+	for py := max(int(minY*supersample), 0); py <= min(int(maxY*supersample), r.img.Rect.Dy()-1); py++ {
+		y := (float64(py) + 0.5) / supersample
+		xs = xs[:0]
+		for i, a := range points {
+			b := points[(i+1)%len(points)]
+			if (a.Y <= y) != (b.Y <= y) {
+				xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			x0 := int(math.Ceil(xs[i]*supersample - 0.5))
+			x1 := int(math.Ceil(xs[i+1]*supersample - 0.5))
+			for px := max(x0, 0); px < min(x1, r.img.Rect.Dx()); px++ {
+				r.img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+// circle approximates a circle as a polygon
+func circle(cx, cy, radius float64, steps int) []point {
+	points := make([]point, steps)
+	for i := range points {
+		a := 2 * math.Pi * float64(i) / float64(steps)
+		points[i] = point{cx + radius*math.Sin(a), cy - radius*math.Cos(a)}
+	}
+	return points
+}
+
+func (r *raster) polyline(points []point, width float64, c color.RGBA) {
+	half := width / 2
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -(b.Y-a.Y)/length*half, (b.X-a.X)/length*half
+		r.fill([]point{{a.X + nx, a.Y + ny}, {b.X + nx, b.Y + ny}, {b.X - nx, b.Y - ny}, {a.X - nx, a.Y - ny}}, c)
+		// round joins between segments
+		if i > 0 && width > 1 {
+			r.fill(circle(a.X, a.Y, half, 12), c)
+		}
+	}
+}
+
+func (r *raster) wedge(cx, cy, radius, from, to float64, c color.RGBA) {
+	if to-from >= 2*math.Pi-1e-9 {
+		r.fill(circle(cx, cy, radius, 128), c)
+		return
+	}
+	steps := int(math.Ceil((to-from)/(2*math.Pi)*128)) + 1
+	points := []point{{cx, cy}}
+	for i := 0; i <= steps; i++ {
+		a := from + (to-from)*float64(i)/float64(steps)
+		points = append(points, point{cx + radius*math.Sin(a), cy - radius*math.Cos(a)})
+	}
+	r.fill(points, c)
+}
+
+// text draws s with the 5x7 bitmap font scaled so that 7 rows are 0.7em
+func (r *raster) text(x, y, size float64, anchor int, bold bool, c color.RGBA, s string) {
+	switch anchor {
+	case anchorMiddle:
+		x -= textWidth(s, size) / 2
+	case anchorEnd:
+		x -= textWidth(s, size)
+	}
+	unit := size / 10
+	for _, ch := range s {
+		glyph := glyphs['?'-32]
+		if ch >= 32 && ch <= 126 {
+			glyph = glyphs[ch-32]
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) == 0 {
+					continue
+				}
+				w := unit
+				if bold {
+					w = unit * 1.6
+				}
+				r.rect(x+float64(col)*unit, y-7*unit+float64(row)*unit, w, unit, c)
+			}
+		}
+		x += 6 * unit
+	}
+}
+
+// encode averages the supersampled pixels and writes the PNG
+func (r *raster) encode(w io.Writer) error {
+	out := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	const n = supersample * supersample
+	for y := 0; y < r.height; y++ {
+		for x := 0; x < r.width; x++ {
+			var sr, sg, sb, sa int
+			for dy := 0; dy < supersample; dy++ {
+				for dx := 0; dx < supersample; dx++ {
+					p := r.img.RGBAAt(x*supersample+dx, y*supersample+dy)
+					sr, sg, sb, sa = sr+int(p.R), sg+int(p.G), sb+int(p.B), sa+int(p.A)
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{uint8(sr / n), uint8(sg / n), uint8(sb / n), uint8(sa / n)})
+		}
+	}
+	return png.Encode(w, out)
+}
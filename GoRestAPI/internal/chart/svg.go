@@ -0,0 +1,62 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// svgSurface collects SVG elements
+type svgSurface struct {
+	buf bytes.Buffer
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (s *svgSurface) rect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&s.buf, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"%s\"/>\n", x, y, w, h, hex(c))
+}
+
+func (s *svgSurface) polyline(points []point, width float64, c color.RGBA) {
+	if len(points) < 2 {
+		return
+	}
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.2f,%.2f", p.X, p.Y)
+	}
+	fmt.Fprintf(&s.buf, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%.2f\" stroke-linejoin=\"round\"/>\n",
+		strings.Join(coords, " "), hex(c), width)
+}
+
+func (s *svgSurface) wedge(cx, cy, r, from, to float64, c color.RGBA) {
+	if to-from >= 2*math.Pi-1e-9 {
+		fmt.Fprintf(&s.buf, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"%.2f\" fill=\"%s\"/>\n", cx, cy, r, hex(c))
+		return
+	}
+	large := 0
+	if to-from > math.Pi {
+		large = 1
+	}
+	fmt.Fprintf(&s.buf, "<path d=\"M%.2f,%.2f L%.2f,%.2f A%.2f,%.2f 0 %d 1 %.2f,%.2f Z\" fill=\"%s\"/>\n",
+		cx, cy, cx+r*math.Sin(from), cy-r*math.Cos(from), r, r, large, cx+r*math.Sin(to), cy-r*math.Cos(to), hex(c))
+}
+
+func (s *svgSurface) text(x, y, size float64, anchor int, bold bool, c color.RGBA, text string) {
+	attrs := ""
+	switch anchor {
+	case anchorMiddle:
+		attrs = ` text-anchor="middle"`
+	case anchorEnd:
+		attrs = ` text-anchor="end"`
+	}
+	if bold {
+		attrs += ` font-weight="bold"`
+	}
+	fmt.Fprintf(&s.buf, "<text x=\"%.2f\" y=\"%.2f\" font-size=\"%.0f\" fill=\"%s\"%s>%s</text>\n", x, y, size, hex(c), attrs, html.EscapeString(text))
+}
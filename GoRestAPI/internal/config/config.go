@@ -3,13 +3,32 @@ package config
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DBPath    string
-	JWTSecret string
+	DBPath      string
+	JWTSecret   string
+	AdminEmails []string
+	Mail        MailConfig
+	// IdempotencyTTL is how long responses to requests with an Idempotency-Key are kept
+	IdempotencyTTL time.Duration
+	// TrashRetention is how long deleted transactions and categories stay restorable
+	TrashRetention time.Duration
+}
+
+// MailConfig selects and configures the mailer used for notification emails
+type MailConfig struct {
+	Mailer       string // "log", "smtp" or "file"
+	From         string
+	Dir          string // where the file mailer drops .eml files
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
 }
 
 var AppConfig Config
@@ -17,14 +36,44 @@ var AppConfig Config
 func LoadConfig() {
 	viper.SetDefault("DB_PATH", "expense_tracker.db")
 	viper.SetDefault("JWT_SECRET", "your_secret_key")
+	viper.SetDefault("ADMIN_EMAILS", "")
+	viper.SetDefault("MAILER", "log")
+	viper.SetDefault("MAIL_FROM", "noreply@expense-tracker.local")
+	viper.SetDefault("MAIL_DIR", "mail")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("TRASH_RETENTION", "720h")
 	viper.AutomaticEnv()
 
 	AppConfig = Config{
-		DBPath:    viper.GetString("DB_PATH"),
-		JWTSecret: viper.GetString("JWT_SECRET"),
+		DBPath:      viper.GetString("DB_PATH"),
+		JWTSecret:   viper.GetString("JWT_SECRET"),
+		AdminEmails: splitList(viper.GetString("ADMIN_EMAILS")),
+		Mail: MailConfig{
+			Mailer:       viper.GetString("MAILER"),
+			From:         viper.GetString("MAIL_FROM"),
+			Dir:          viper.GetString("MAIL_DIR"),
+			SMTPHost:     viper.GetString("SMTP_HOST"),
+			SMTPPort:     viper.GetInt("SMTP_PORT"),
+			SMTPUsername: viper.GetString("SMTP_USERNAME"),
+			SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+		},
+		IdempotencyTTL: viper.GetDuration("IDEMPOTENCY_TTL"),
+		TrashRetention: viper.GetDuration("TRASH_RETENTION"),
 	}
 
 	if AppConfig.JWTSecret == "your_secret_key" {
 		log.Println("[WARN] Using default JWT secret. Set JWT_SECRET env variable in production.")
 	}
 }
+
+// splitList parses a comma separated env value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -5,16 +5,18 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"expense-tracker/internal/models"
+	"expense-tracker/internal/search"
 )
 
 var DB *gorm.DB
 
 func InitDB() {
-	db, err := gorm.Open(sqlite.Open("expense_tracker.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(AppConfig.DBPath), &gorm.Config{})
 	if err != nil {
 		log.Fatal("failed to connect database: ", err)
 	}
 	// Auto-migrate models
-	db.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{})
+	db.AutoMigrate(&models.User{}, &models.Category{}, &models.Transaction{}, &models.CategoryTemplate{}, &models.Budget{}, &models.Notification{}, &models.Webhook{}, &models.BudgetAlert{}, &models.EnvelopeAssignment{}, &models.Account{}, &models.Goal{}, &models.RecurringTransaction{}, &models.Asset{}, &models.AssetValuation{}, &models.NetWorthSnapshot{}, &models.AnomalyAlert{}, &models.DigestSubscription{}, &models.Tag{}, &models.TransactionTag{}, &models.MonthlyRollup{}, &models.SavedView{}, &models.IdempotencyKey{}, &models.AuditEntry{})
+	search.Setup(db)
 	DB = db
 }
@@ -7,7 +7,9 @@ import (
 	"os"
 )
 
-// RunMigrations applies all SQL files in the migrations folder to the SQLite DB
+// RunMigrations applies all SQL files in the migrations folder to the SQLite DB.
+// Applied files are recorded in schema_migrations so that non-idempotent
+// statements such as ALTER TABLE only ever run once.
 func RunMigrations(dbPath string) {
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
@@ -15,12 +17,24 @@ func RunMigrations(dbPath string) {
 	}
 	defer db.Close()
 
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		log.Fatalf("failed to create schema_migrations table: %v", err)
+	}
+
 	files, err := os.ReadDir("migrations")
 	if err != nil {
 		log.Fatalf("failed to read migrations dir: %v", err)
 	}
 	for _, f := range files {
 		if f.IsDir() { continue }
+		var applied int
+		if err := db.QueryRow("SELECT COUNT(1) FROM schema_migrations WHERE name = ?", f.Name()).Scan(&applied); err != nil {
+			log.Fatalf("failed to check migration %s: %v", f.Name(), err)
+		}
+		if applied > 0 { continue }
 		content, err := os.ReadFile("migrations/" + f.Name())
 		if err != nil {
 			log.Fatalf("failed to read migration %s: %v", f.Name(), err)
@@ -29,6 +43,9 @@ func RunMigrations(dbPath string) {
 		if err != nil {
 			log.Fatalf("migration %s failed: %v", f.Name(), err)
 		}
+		if _, err := db.Exec("INSERT INTO schema_migrations (name) VALUES (?)", f.Name()); err != nil {
+			log.Fatalf("failed to record migration %s: %v", f.Name(), err)
+		}
 		log.Printf("applied migration: %s", f.Name())
 	}
 }
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// migrate runs the named files of the migrations folder against db
+func migrate(t *testing.T, db *sql.DB, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join("..", "..", "migrations", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(content)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestCategoryKindMigration(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrate(t, db, "001_create_users.sql", "002_create_categories.sql", "003_create_transactions.sql")
+	_, err = db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'a@example.com', 'x');
+		INSERT INTO categories (id, name, user_id) VALUES (1, 'Salary', 1), (2, 'Food', 1), (3, 'Savings', 1), (4, 'Unused', 1);
+		INSERT INTO transactions (amount, date, category_id, user_id) VALUES
+			(2000, '2025-01-31', 1, 1), (1900, '2025-02-28', 1, 1),
+			(-40, '2025-02-01', 2, 1), (-15, '2025-02-02', 2, 1),
+			(-500, '2025-02-01', 3, 1), (120, '2025-02-15', 3, 1)`)
+	assert.NoError(t, err)
+	migrate(t, db, "004_add_category_metadata.sql")
+
+	kinds := map[string]string{}
+	rows, err := db.Query("SELECT name, kind FROM categories")
+	assert.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name, kind string
+		rows.Scan(&name, &kind)
+		kinds[name] = kind
+	}
+	assert.Equal(t, map[string]string{"Salary": "income", "Food": "expense", "Savings": "transfer", "Unused": "expense"}, kinds)
+}
@@ -27,3 +27,13 @@ func SeedUsers() {
 		}
 	}
 }
+
+// PromoteAdmins grants admin rights to the users listed in ADMIN_EMAILS
+func PromoteAdmins() {
+	if len(AppConfig.AdminEmails) == 0 {
+		return
+	}
+	if err := DB.Model(&models.User{}).Where("email IN ?", AppConfig.AdminEmails).Update("is_admin", true).Error; err != nil {
+		log.Printf("failed to promote admins: %v", err)
+	}
+}
@@ -2,12 +2,15 @@ package config
 
 import (
 	"expense-tracker/internal/models"
+	"expense-tracker/internal/rollup"
+	"expense-tracker/internal/search"
+	"expense-tracker/internal/templates"
 	"golang.org/x/crypto/bcrypt"
 	"log"
 	"time"
 )
 
-// SeedDemoData inserts demo data for all tables
+// SeedDemoData creates the demo user with the default category template and a sample transaction
 func SeedDemoData() {
 	// Seed user
 	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
@@ -16,10 +19,19 @@ func SeedDemoData() {
 		log.Printf("failed to seed user: %v", err)
 	}
 
-	// Seed category
-	category := models.Category{Name: "Groceries", UserID: user.ID}
-	if err := DB.Where(models.Category{Name: category.Name, UserID: user.ID}).FirstOrCreate(&category).Error; err != nil {
-		log.Printf("failed to seed category: %v", err)
+	// Seed categories from the default template
+	tmpl, err := templates.Find(DB, templates.DefaultKey, templates.DefaultLocale)
+	if err != nil {
+		log.Printf("failed to load default category template: %v", err)
+		return
+	}
+	if _, err := templates.Apply(DB, user.ID, tmpl); err != nil {
+		log.Printf("failed to seed categories: %v", err)
+	}
+	var category models.Category
+	if err := DB.Where("user_id = ? AND name = ?", user.ID, "Groceries").First(&category).Error; err != nil {
+		log.Printf("failed to find demo category: %v", err)
+		return
 	}
 
 	// Seed transaction
@@ -38,4 +50,10 @@ func SeedDemoData() {
 	if err := DB.Where(models.Transaction{Description: transaction.Description, UserID: user.ID}).FirstOrCreate(&transaction).Error; err != nil {
 		log.Printf("failed to seed transaction: %v", err)
 	}
+	if err := rollup.Rebuild(DB, user.ID); err != nil {
+		log.Printf("failed to rebuild demo rollups: %v", err)
+	}
+	if err := search.Index(DB, transaction.ID); err != nil {
+		log.Printf("failed to index demo transaction: %v", err)
+	}
 }
@@ -0,0 +1,192 @@
+// Package document describes a report once, as a title and a list of tabular
+// sections, and renders it either as HTML or as a paginated PDF.
+package document
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"expense-tracker/internal/pdf"
+)
+
+// Column of a table. Width is relative to the other columns.
+type Column struct {
+	Title string
+	Width float64
+	Right bool
+}
+
+// Table is a list of rows with an optional total row
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+	Total   []string
+}
+
+// Section is a headed table. Empty is shown instead when the table has no rows.
+type Section struct {
+	Heading string
+	Table   Table
+	Empty   string
+}
+
+// Document is a report with a title and sections
+type Document struct {
+	Title    string
+	Subtitle string
+	Sections []Section
+}
+
+var htmlTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; margin: 40px; }
+h1 { font-size: 22px; margin-bottom: 4px; }
+h2 { font-size: 16px; margin-top: 28px; }
+table { border-collapse: collapse; width: 100%; }
+th { background: #e6e6e6; text-align: left; }
+th, td { padding: 4px 6px; border-bottom: 1px solid #ddd; }
+.right { text-align: right; }
+tfoot td { font-weight: bold; border-top: 1px solid #222; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">{{.Subtitle}}</p>
+{{range .Sections}}{{$cols := .Table.Columns}}
+<h2>{{.Heading}}</h2>
+{{if .Table.Rows}}<table>
+<thead><tr>{{range $cols}}<th{{if .Right}} class="right"{{end}}>{{.Title}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Table.Rows}}<tr>{{range $i, $cell := .}}<td{{if (index $cols $i).Right}} class="right"{{end}}>{{$cell}}</td>{{end}}</tr>
+{{end}}</tbody>
+{{if .Table.Total}}<tfoot><tr>{{range $i, $cell := .Table.Total}}<td{{if (index $cols $i).Right}} class="right"{{end}}>{{$cell}}</td>{{end}}</tr></tfoot>{{end}}
+</table>{{else}}<p class="muted">{{.Empty}}</p>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the document as a standalone HTML page
+func (d Document) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, d)
+}
+
+// PDF layout in points
+const (
+	margin     = 40.0
+	footerY    = 24.0
+	bottom     = 50.0
+	rowHeight  = 14.0
+	fontSize   = 9.0
+	cellMargin = 4.0
+)
+
+// pdfWriter lays out a document top to bottom, starting new pages as needed
+type pdfWriter struct {
+	doc *pdf.Document
+	y   float64
+}
+
+func (p *pdfWriter) newPage() {
+	p.doc.AddPage()
+	p.y = pdf.PageHeight - margin
+}
+
+// ensure starts a new page unless height points are left on the current one
+func (p *pdfWriter) ensure(height float64) bool {
+	if p.y-height < bottom {
+		p.newPage()
+		return true
+	}
+	return false
+}
+
+// row draws one table row with the cells clipped to their columns
+func (p *pdfWriter) row(cols []Column, widths []float64, cells []string, bold bool) {
+	p.y -= rowHeight
+	x := margin
+	for i, col := range cols {
+		if i < len(cells) {
+			text := pdf.Truncate(cells[i], widths[i]-2*cellMargin, fontSize, bold)
+			tx := x + cellMargin
+			if col.Right {
+				tx = x + widths[i] - cellMargin - pdf.TextWidth(text, fontSize, bold)
+			}
+			p.doc.Text(tx, p.y+4, fontSize, bold, text)
+		}
+		x += widths[i]
+	}
+}
+
+func (p *pdfWriter) header(cols []Column, widths []float64) {
+	p.doc.FillRect(margin, p.y-rowHeight, pdf.PageWidth-2*margin, rowHeight, 0.9)
+	titles := make([]string, len(cols))
+	for i, col := range cols {
+		titles[i] = col.Title
+	}
+	p.row(cols, widths, titles, true)
+}
+
+// WritePDF renders the document as an A4 PDF. Tables continue across pages
+// with their header repeated, and every page gets a numbered footer.
+func (d Document) WritePDF(w io.Writer) error {
+	p := &pdfWriter{doc: pdf.New()}
+	p.newPage()
+	p.y -= 18
+	p.doc.Text(margin, p.y, 18, true, d.Title)
+	if d.Subtitle != "" {
+		p.y -= 16
+		p.doc.Text(margin, p.y, 10, false, d.Subtitle)
+	}
+	width := pdf.PageWidth - 2*margin
+	for _, s := range d.Sections {
+		// keep the heading with the table header and the first row
+		p.ensure(30 + 2*rowHeight)
+		p.y -= 30
+		p.doc.Text(margin, p.y, 12, true, s.Heading)
+		p.y -= 4
+		if len(s.Table.Rows) == 0 {
+			p.y -= rowHeight
+			p.doc.Text(margin, p.y+4, fontSize, false, s.Empty)
+			continue
+		}
+		var total float64
+		for _, col := range s.Table.Columns {
+			total += col.Width
+		}
+		widths := make([]float64, len(s.Table.Columns))
+		for i, col := range s.Table.Columns {
+			widths[i] = width * col.Width / total
+		}
+		p.header(s.Table.Columns, widths)
+		for _, cells := range s.Table.Rows {
+			if p.ensure(rowHeight) {
+				p.header(s.Table.Columns, widths)
+			}
+			p.row(s.Table.Columns, widths, cells, false)
+			p.doc.Line(margin, p.y, margin+width, p.y, 0.3, 0.85)
+		}
+		if s.Table.Total != nil {
+			if p.ensure(rowHeight) {
+				p.header(s.Table.Columns, widths)
+			}
+			p.doc.Line(margin, p.y, margin+width, p.y, 0.8, 0)
+			p.row(s.Table.Columns, widths, s.Table.Total, true)
+		}
+	}
+
+	pages := p.doc.PageCount()
+	for i := 0; i < pages; i++ {
+		p.doc.SetPage(i)
+		footer := fmt.Sprintf("Page %d of %d", i+1, pages)
+		p.doc.Text(margin, footerY, 8, false, d.Title)
+		p.doc.Text(pdf.PageWidth-margin-pdf.TextWidth(footer, 8, false), footerY, 8, false, footer)
+	}
+	_, err := p.doc.WriteTo(w)
+	return err
+}
@@ -0,0 +1,58 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sample(rows int) Document {
+	table := Table{Columns: []Column{{Title: "Date", Width: 1}, {Title: "Amount", Width: 1, Right: true}}, Total: []string{"Total", "0.00"}}
+	for i := 0; i < rows; i++ {
+		table.Rows = append(table.Rows, []string{fmt.Sprintf("2025-07-%02d", i%31+1), "-1.00"})
+	}
+	return Document{Title: "Statement <July>", Subtitle: "demo", Sections: []Section{
+		{Heading: "Transactions", Table: table},
+		{Heading: "Budgets", Empty: "No budgets"},
+	}}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, sample(2).WriteHTML(&buf))
+	html := buf.String()
+	assert.Contains(t, html, "Statement &lt;July&gt;")
+	assert.Contains(t, html, `<td class="right">-1.00</td>`)
+	assert.Contains(t, html, "No budgets")
+}
+
+func TestWritePDFPaginates(t *testing.T) {
+	var short, long bytes.Buffer
+	assert.NoError(t, sample(5).WritePDF(&short))
+	assert.NoError(t, sample(200).WritePDF(&long))
+	assert.Contains(t, short.String(), "/Count 1")
+	assert.Contains(t, long.String(), "/Count 5")
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, sample(2).WriteText(&buf))
+	assert.Equal(t, `Statement <July>
+================
+demo
+
+Transactions
+------------
+Date        Amount
+2025-07-01   -1.00
+2025-07-02   -1.00
+------------------
+Total         0.00
+
+Budgets
+-------
+No budgets
+`, buf.String())
+}
@@ -0,0 +1,70 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// pad aligns s in a column of the given width
+func pad(s string, width int, right bool) string {
+	gap := strings.Repeat(" ", width-utf8.RuneCountInString(s))
+	if right {
+		return gap + s
+	}
+	return s + gap
+}
+
+// WriteText renders the document as plain text with aligned columns, e.g. for
+// the text part of an email
+func (d Document) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n", d.Title, strings.Repeat("=", utf8.RuneCountInString(d.Title)))
+	if d.Subtitle != "" {
+		fmt.Fprintf(&b, "%s\n", d.Subtitle)
+	}
+	for _, s := range d.Sections {
+		fmt.Fprintf(&b, "\n%s\n%s\n", s.Heading, strings.Repeat("-", utf8.RuneCountInString(s.Heading)))
+		if len(s.Table.Rows) == 0 {
+			fmt.Fprintf(&b, "%s\n", s.Empty)
+			continue
+		}
+		lines := [][]string{make([]string, len(s.Table.Columns))}
+		for i, col := range s.Table.Columns {
+			lines[0][i] = col.Title
+		}
+		lines = append(lines, s.Table.Rows...)
+		if s.Table.Total != nil {
+			lines = append(lines, s.Table.Total)
+		}
+		widths := make([]int, len(s.Table.Columns))
+		for _, cells := range lines {
+			for i := range widths {
+				if i < len(cells) && utf8.RuneCountInString(cells[i]) > widths[i] {
+					widths[i] = utf8.RuneCountInString(cells[i])
+				}
+			}
+		}
+		for n, cells := range lines {
+			if s.Table.Total != nil && n == len(lines)-1 {
+				total := 0
+				for _, width := range widths {
+					total += width + 2
+				}
+				fmt.Fprintf(&b, "%s\n", strings.Repeat("-", total-2))
+			}
+			parts := make([]string, len(widths))
+			for i, width := range widths {
+				cell := ""
+				if i < len(cells) {
+					cell = cells[i]
+				}
+				parts[i] = pad(cell, width, s.Table.Columns[i].Right)
+			}
+			fmt.Fprintf(&b, "%s\n", strings.TrimRight(strings.Join(parts, "  "), " "))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,105 @@
+package filter
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	n, err := Parse("category in (1,2,3) and not tag:reimbursed or amount < -500")
+	assert.NoError(t, err)
+	assert.Equal(t, `((category IN (1, 2, 3) AND NOT tag = "reimbursed") OR amount < -500)`, n.String())
+
+	n, err = Parse(`date >= -30d AND (payee ~ "Corner Market" OR description != 'rent')`)
+	assert.NoError(t, err)
+	assert.Equal(t, `(date >= -30d AND (payee ~ "Corner Market" OR description != "rent"))`, n.String())
+
+	for _, s := range []string{
+		"",
+		"amount",
+		"amount <",
+		"amount < 'ten'",
+		"date >= 30 days",
+		"date ~ today",
+		"category in (1, 2",
+		"balance > 0",
+		"payee = 'unterminated",
+		"(amount > 0",
+		"amount > 0 amount < 5",
+		"tag < 'a'",
+		strings.Repeat("not ", 40) + "amount > 0",
+	} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	today := time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+	n, err := Parse("category in (1, 'Groceries') and not tag:reimbursed and date >= -1m and payee ~ '50%'")
+	assert.NoError(t, err)
+	sql, args, err := SQL(n, 7, today)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(sql, "?"), len(args))
+	assert.Contains(t, sql, "transactions.category_id IN (?) OR transactions.category_id IN (SELECT id FROM categories WHERE user_id = ? AND LOWER(name) IN (?))")
+	assert.Contains(t, sql, "NOT COALESCE(EXISTS (SELECT 1 FROM transaction_tags")
+	assert.Equal(t, []interface{}{1.0, uint(7), "groceries", uint(7), "reimbursed", time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC), `%50\%%`}, args)
+
+	// values never end up in the SQL text
+	n, _ = Parse(`description = "x' OR 1=1 --"`)
+	sql, args, _ = SQL(n, 7, today)
+	assert.NotContains(t, sql, "1=1")
+	assert.Equal(t, []interface{}{"x' or 1=1 --"}, args)
+}
+
+func TestSQLNot(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE transactions (id INTEGER PRIMARY KEY, account_id INTEGER, amount REAL);
+		INSERT INTO transactions VALUES (1, 1, -5), (2, 2, -5), (3, NULL, -5), (4, NULL, 5)`)
+	assert.NoError(t, err)
+	ids := func(expr string) []int {
+		n, err := Parse(expr)
+		assert.NoError(t, err, expr)
+		cond, args, err := SQL(n, 7, time.Now())
+		assert.NoError(t, err, expr)
+		rows, err := db.Query("SELECT id FROM transactions WHERE "+cond+" ORDER BY id", args...)
+		if !assert.NoError(t, err, cond) {
+			return nil
+		}
+		defer rows.Close()
+		var ids []int
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	n, _ := Parse("not account = 1")
+	cond, _, _ := SQL(n, 7, time.Now())
+	assert.Equal(t, "NOT COALESCE(transactions.account_id IN (?), 0)", cond)
+	assert.Equal(t, []int{2, 3, 4}, ids("not account = 1"))
+	assert.Equal(t, ids("account != 1"), ids("not account = 1"))
+	assert.Equal(t, ids("account_id != 1"), ids("not account_id = 1"))
+	assert.Equal(t, []int{2, 3}, ids("not (account = 1 or amount > 0)"))
+	assert.Equal(t, []int{1}, ids("not not account = 1"))
+}
+
+func TestResolveDate(t *testing.T) {
+	today := time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC)
+	for text, want := range map[string]string{"today": "2025-08-15", "-30d": "2025-07-16", "-2w": "2025-08-01", "+1y": "2026-08-15", "2025-01-31": "2025-01-31"} {
+		d, err := resolveDate(Value{Kind: "date", Text: text}, today)
+		assert.NoError(t, err)
+		assert.Equal(t, want, d.Format("2006-01-02"), text)
+	}
+}
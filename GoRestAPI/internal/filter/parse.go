@@ -0,0 +1,368 @@
+// Package filter parses the transaction filter language used by
+// GET /transactions?filter= and by saved views, for example
+//
+//	category in (1, 2, 3) and not tag:reimbursed or amount < -500
+//	date >= -30d and payee ~ "amazon"
+//
+// An expression combines comparisons with AND, OR, NOT and parentheses; AND
+// binds tighter than OR. Comparisons are field op value with the operators
+// = != < <= > >= ~ (contains) and IN (list), and field:value as a shorthand
+// for =. Dates are YYYY-MM-DD or relative to today: -30d, -2w, -3m, -1y, today.
+// Parse builds an AST that SQL compiles to a parameterized condition.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxLength bounds the length of an expression
+const MaxLength = 1000
+
+// maxDepth bounds how deeply expressions nest
+const maxDepth = 32
+
+// Node is a node of a parsed expression: And, Or, Not or Comparison
+type Node interface {
+	String() string
+}
+
+type And struct{ Left, Right Node }
+type Or struct{ Left, Right Node }
+type Not struct{ X Node }
+
+// Comparison compares a field with one value, or with a list for IN
+type Comparison struct {
+	Field  string
+	Op     string
+	Values []Value
+}
+
+// Value is a literal: a number, a string or a date
+type Value struct {
+	Kind string // "number", "string" or "date"
+	Text string // the literal as written, without quotes
+	Num  float64
+}
+
+func (n And) String() string { return "(" + n.Left.String() + " AND " + n.Right.String() + ")" }
+func (n Or) String() string  { return "(" + n.Left.String() + " OR " + n.Right.String() + ")" }
+func (n Not) String() string { return "NOT " + n.X.String() }
+
+func (n Comparison) String() string {
+	values := make([]string, len(n.Values))
+	for i, v := range n.Values {
+		values[i] = v.String()
+	}
+	if n.Op == "in" {
+		return n.Field + " IN (" + strings.Join(values, ", ") + ")"
+	}
+	return n.Field + " " + n.Op + " " + values[0]
+}
+
+func (v Value) String() string {
+	if v.Kind == "string" {
+		return strconv.Quote(v.Text)
+	}
+	return v.Text
+}
+
+// Error is a syntax error at a byte offset of the expression
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos+1)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokDate
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits an expression into tokens
+func lex(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, &Error{i, "unterminated string"}
+			}
+			tokens = append(tokens, token{tokString, b.String(), i})
+			i = j + 1
+		case strings.ContainsRune("=!<>~:", rune(c)):
+			op := string(c)
+			if i+1 < len(s) && s[i+1] == '=' && c != '=' && c != '~' && c != ':' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &Error{i, "unexpected '!'"}
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		case c == '-' || c == '+' || c == '.' || isDigit(c):
+			j := i + 1
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.' || s[j] == '-' || unicode.IsLetter(rune(s[j]))) {
+				j++
+			}
+			text := s[i:j]
+			kind, err := classifyLiteral(text)
+			if err != nil {
+				return nil, &Error{i, err.Error()}
+			}
+			tokens = append(tokens, token{kind, text, i})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || isDigit(s[j]) || unicode.IsLetter(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, s[i:j], i})
+			i = j
+		default:
+			return nil, &Error{i, fmt.Sprintf("unexpected %q", c)}
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)}), nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// classifyLiteral tells numbers, dates and relative dates apart
+func classifyLiteral(text string) (tokenKind, error) {
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return tokNumber, nil
+	}
+	if _, err := parseDate(text); err == nil {
+		return tokDate, nil
+	}
+	if _, _, err := parseRelative(text); err == nil {
+		return tokDate, nil
+	}
+	return tokEOF, fmt.Errorf("invalid literal %q", text)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the given keyword and consumes it
+func (p *parser) keyword(k string) bool {
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, k) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// Parse parses a filter expression
+func Parse(s string) (Node, error) {
+	if len(s) > MaxLength {
+		return nil, &Error{MaxLength, fmt.Sprintf("expression longer than %d characters", MaxLength)}
+	}
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{0, "empty expression"}
+	}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return n, nil
+}
+
+func (p *parser) or() (Node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (Node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, &Error{p.peek().pos, "expression nested too deeply"}
+	}
+	if p.keyword("not") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Not{x}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, &Error{t.pos, "expected ')'"}
+		}
+		return n, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Node, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return nil, &Error{t.pos, "expected a field name"}
+	}
+	field := strings.ToLower(t.text)
+	f, ok := fields[field]
+	if !ok {
+		return nil, &Error{t.pos, fmt.Sprintf("unknown field %q", t.text)}
+	}
+	n := Comparison{Field: field}
+	opTok := p.peek()
+	if p.keyword("in") {
+		n.Op = "in"
+		if t := p.next(); t.kind != tokLParen {
+			return nil, &Error{t.pos, "expected '(' after IN"}
+		}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			n.Values = append(n.Values, v)
+			if t := p.next(); t.kind == tokRParen {
+				break
+			} else if t.kind != tokComma {
+				return nil, &Error{t.pos, "expected ',' or ')'"}
+			}
+		}
+	} else {
+		op := p.next()
+		if op.kind != tokOp {
+			return nil, &Error{op.pos, "expected an operator"}
+		}
+		n.Op = op.text
+		if n.Op == ":" {
+			n.Op = "="
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.Values = []Value{v}
+	}
+	if !strings.Contains(f.ops, " "+n.Op+" ") {
+		return nil, &Error{opTok.pos, fmt.Sprintf("operator %s is not supported for %s", strings.ToUpper(n.Op), field)}
+	}
+	for i, v := range n.Values {
+		if !f.accepts(v.Kind) {
+			return nil, &Error{t.pos, fmt.Sprintf("%s cannot be compared with %s", field, v)}
+		}
+		if f.kind == "string" && v.Kind != "string" {
+			// unquoted numbers are fine for text fields
+			n.Values[i].Kind = "string"
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) value() (Value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		num, _ := strconv.ParseFloat(t.text, 64)
+		return Value{Kind: "number", Text: t.text, Num: num}, nil
+	case tokDate:
+		return Value{Kind: "date", Text: t.text}, nil
+	case tokString:
+		return Value{Kind: "string", Text: t.text}, nil
+	case tokIdent:
+		// bare words are strings, e.g. tag:reimbursed; today is a date
+		if strings.EqualFold(t.text, "today") {
+			return Value{Kind: "date", Text: "today"}, nil
+		}
+		return Value{Kind: "string", Text: t.text}, nil
+	}
+	return Value{}, &Error{t.pos, "expected a value"}
+}
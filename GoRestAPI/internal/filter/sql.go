@@ -0,0 +1,231 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field describes a filterable field. kind is the type of value it takes and
+// ops the operators it supports, space-separated with spaces around.
+type field struct {
+	kind   string
+	ops    string
+	column string
+}
+
+const (
+	orderedOps = " = != < <= > >= in "
+	textOps    = " = != ~ in "
+	setOps     = " = != in "
+)
+
+var fields = map[string]field{
+	"amount":      {"number", orderedOps, "transactions.amount"},
+	"date":        {"date", orderedOps, "transactions.date"},
+	"category":    {"ref", setOps, "transactions.category_id"},
+	"category_id": {"number", setOps, "transactions.category_id"},
+	"account":     {"ref", setOps, "transactions.account_id"},
+	"account_id":  {"number", setOps, "transactions.account_id"},
+	"payee":       {"string", textOps, "transactions.payee"},
+	"description": {"string", textOps, "transactions.description"},
+	"notes":       {"string", textOps, "transactions.notes"},
+	"tag":         {"string", setOps, ""},
+}
+
+// accepts reports whether the field can be compared with a value of the kind
+func (f field) accepts(kind string) bool {
+	switch f.kind {
+	case "ref":
+		// an ID or a name
+		return kind == "number" || kind == "string"
+	case "string":
+		return kind == "string" || kind == "number"
+	}
+	return f.kind == kind
+}
+
+// Fields lists the filterable field names
+func Fields() []string {
+	return []string{"amount", "date", "category", "category_id", "account", "account_id", "payee", "description", "notes", "tag"}
+}
+
+// parseDate parses a YYYY-MM-DD date
+func parseDate(s string) (time.Time, error) {
+	return time.Parse("2006-01-02", s)
+}
+
+// parseRelative parses an offset from today such as -30d, +2w, -3m or -1y
+func parseRelative(s string) (int, byte, error) {
+	if len(s) < 3 || (s[0] != '-' && s[0] != '+') {
+		return 0, 0, errors.New("not a relative date")
+	}
+	unit := s[len(s)-1]
+	if !strings.ContainsRune("dwmy", rune(unit)) {
+		return 0, 0, errors.New("not a relative date")
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, unit, nil
+}
+
+// resolveDate turns a date literal into a calendar date, relative to today
+func resolveDate(v Value, today time.Time) (time.Time, error) {
+	if v.Text == "today" {
+		return today, nil
+	}
+	if d, err := parseDate(v.Text); err == nil {
+		return d, nil
+	}
+	n, unit, err := parseRelative(v.Text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s", v.Text)
+	}
+	switch unit {
+	case 'w':
+		return today.AddDate(0, 0, 7*n), nil
+	case 'm':
+		return today.AddDate(0, n, 0), nil
+	case 'y':
+		return today.AddDate(n, 0, 0), nil
+	}
+	return today.AddDate(0, 0, n), nil
+}
+
+// SQL compiles an expression into a condition on the transactions table of
+// the given user. Every value is a parameter. today is the current calendar
+// date at UTC midnight, the base of relative dates.
+func SQL(n Node, userID uint, today time.Time) (string, []interface{}, error) {
+	c := &compiler{userID: userID, today: today}
+	sql, err := c.compile(n)
+	return sql, c.args, err
+}
+
+type compiler struct {
+	userID uint
+	today  time.Time
+	args   []interface{}
+}
+
+func (c *compiler) compile(n Node) (string, error) {
+	switch n := n.(type) {
+	case And:
+		return c.binary(n.Left, "AND", n.Right)
+	case Or:
+		return c.binary(n.Left, "OR", n.Right)
+	case Not:
+		// a comparison with a missing value is NULL in SQL; it counts as false,
+		// so that not account = 1 matches transactions without an account
+		// like account != 1 does
+		x, err := c.compile(n.X)
+		return "NOT COALESCE(" + x + ", 0)", err
+	case Comparison:
+		return c.comparison(n)
+	}
+	return "", fmt.Errorf("filter: unexpected node %T", n)
+}
+
+func (c *compiler) binary(left Node, op string, right Node) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// placeholders adds the values as parameters and returns "?" or "(?, ?, ...)"
+func (c *compiler) placeholders(values []interface{}, list bool) string {
+	c.args = append(c.args, values...)
+	if !list {
+		return "?"
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")"
+}
+
+func (c *compiler) comparison(n Comparison) (string, error) {
+	f := fields[n.Field]
+	values := make([]interface{}, len(n.Values))
+	for i, v := range n.Values {
+		switch {
+		case f.kind == "date":
+			d, err := resolveDate(v, c.today)
+			if err != nil {
+				return "", err
+			}
+			values[i] = d
+		case v.Kind == "number":
+			values[i] = v.Num
+		case f.kind == "string" || f.kind == "ref":
+			values[i] = strings.ToLower(v.Text)
+		}
+	}
+	list := n.Op == "in"
+	switch {
+	case n.Field == "tag":
+		c.args = append(c.args, c.userID)
+		cond := "EXISTS (SELECT 1 FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.transaction_id = transactions.id AND tg.user_id = ? AND tg.name IN " + c.placeholders(values, true) + ")"
+		if n.Op == "!=" {
+			cond = "NOT " + cond
+		}
+		return cond, nil
+	case f.kind == "ref":
+		return c.reference(n, f, values)
+	case n.Op == "~":
+		// LIKE wildcards in the value match literally
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(values[0].(string))
+		return "LOWER(COALESCE(" + f.column + ", '')) LIKE " + c.placeholders([]interface{}{"%" + escaped + "%"}, false) + ` ESCAPE '\'`, nil
+	case f.kind == "string":
+		column := "LOWER(COALESCE(" + f.column + ", ''))"
+		if list {
+			return column + " IN " + c.placeholders(values, true), nil
+		}
+		return column + " " + n.Op + " " + c.placeholders(values, false), nil
+	case list:
+		return f.column + " IN " + c.placeholders(values, true), nil
+	case n.Op == "!=":
+		// a missing account is different from any account
+		return "(" + f.column + " != " + c.placeholders(values, false) + " OR " + f.column + " IS NULL)", nil
+	}
+	return f.column + " " + n.Op + " " + c.placeholders(values, false), nil
+}
+
+// reference compares a category or account with IDs or case-insensitive names
+func (c *compiler) reference(n Comparison, f field, values []interface{}) (string, error) {
+	// trashed categories still match by name, like their transactions still count
+	table := "categories"
+	if n.Field == "account" {
+		table = "accounts"
+	}
+	var ids, names []interface{}
+	for _, v := range values {
+		if _, ok := v.(string); ok {
+			names = append(names, v)
+		} else {
+			ids = append(ids, v)
+		}
+	}
+	var parts []string
+	if len(ids) > 0 {
+		parts = append(parts, f.column+" IN "+c.placeholders(ids, true))
+	}
+	if len(names) > 0 {
+		c.args = append(c.args, c.userID)
+		parts = append(parts, f.column+" IN (SELECT id FROM "+table+" WHERE user_id = ? AND LOWER(name) IN "+c.placeholders(names, true)+")")
+	}
+	cond := strings.Join(parts, " OR ")
+	if len(parts) > 1 {
+		cond = "(" + cond + ")"
+	}
+	if n.Op == "!=" {
+		cond = "(NOT " + cond + " OR " + f.column + " IS NULL)"
+	}
+	return cond, nil
+}
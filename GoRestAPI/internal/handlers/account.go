@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountInput struct {
+	Name           string  `json:"name" binding:"required"`
+	Type           string  `json:"type" binding:"required,oneof=checking savings credit cash investment"`
+	OpeningBalance float64 `json:"opening_balance"`
+	Archived       bool    `json:"archived"`
+}
+
+type AccountBalance struct {
+	models.Account
+	Balance float64 `json:"balance"`
+}
+
+// accountBalance returns the balance of an account at the start of the given time:
+// the opening balance plus all earlier transactions.
+func accountBalance(acc models.Account, before time.Time) float64 {
+	var sum float64
+	config.DB.Model(&models.Transaction{}).Where("account_id = ? AND date < ?", acc.ID, before).
+		Select("COALESCE(SUM(amount), 0)").Scan(&sum)
+	return acc.OpeningBalance + sum
+}
+
+// ListAccounts returns the accounts of the authenticated user with their current balances
+// @Summary List accounts
+// @Description Get the accounts of the current user with their balances. Archived accounts are hidden unless include_archived is true.
+// @Tags accounts
+// @Security BearerAuth
+// @Produce json
+// @Param include_archived query bool false "Include archived accounts"
+// @Success 200 {array} AccountBalance
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /accounts [get]
+func ListAccounts(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var accounts []models.Account
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("include_archived") != "true" {
+		query = query.Where("archived = ?", false)
+	}
+	if err := query.Order("name").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	list := make([]AccountBalance, 0, len(accounts))
+	for _, acc := range accounts {
+		list = append(list, AccountBalance{Account: acc, Balance: accountBalance(acc, now.AddDate(0, 0, 1))})
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateAccount creates a new account for the authenticated user
+// @Summary Create account
+// @Description Create a new account for the current user
+// @Tags accounts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body AccountInput true "Account info"
+// @Success 201 {object} models.Account
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /accounts [post]
+func CreateAccount(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input AccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	acc := models.Account{UserID: userID, Name: input.Name, Type: input.Type, OpeningBalance: input.OpeningBalance, Archived: input.Archived}
+	if err := config.DB.Create(&acc).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, acc)
+}
+
+// UpdateAccount updates an account for the authenticated user
+// @Summary Update account
+// @Description Update an account for the current user
+// @Tags accounts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param input body AccountInput true "Account info"
+// @Success 200 {object} models.Account
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /accounts/{id} [put]
+func UpdateAccount(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	var acc models.Account
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&acc).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	var input AccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	acc.Name = input.Name
+	acc.Type = input.Type
+	acc.OpeningBalance = input.OpeningBalance
+	acc.Archived = input.Archived
+	config.DB.Save(&acc)
+	c.JSON(http.StatusOK, acc)
+}
+
+// DeleteAccount deletes an account without transactions
+// @Summary Delete account
+// @Description Delete an account of the current user. Accounts that still have transactions must be archived instead.
+// @Tags accounts
+// @Security BearerAuth
+// @Param id path int true "Account ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 409 {object} gin.H{"error":string}
+// @Router /accounts/{id} [delete]
+func DeleteAccount(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	var count int64
+	config.DB.Model(&models.Transaction{}).Where("account_id = ? AND user_id = ?", id, userID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account has transactions; archive it instead"})
+		return
+	}
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Account{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/notify"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// Anomaly types
+const (
+	AnomalyLargeTransaction = "large_transaction"
+	AnomalyPayeeAmount      = "payee_amount"
+	AnomalyCategorySpike    = "category_spike"
+)
+
+const (
+	// anomalyHistoryDays is how far back baselines are computed
+	anomalyHistoryDays = 365
+	// anomalyRobustZ is the modified z-score (median absolute deviation) above
+	// which a single transaction is an outlier
+	anomalyRobustZ = 3.5
+	// anomalySpikeZ is the z-score above which a category total is a spike
+	anomalySpikeZ = 2.0
+	// minimum number of past transactions or periods for a baseline
+	anomalyMinCategoryHistory = 5
+	anomalyMinPayeeHistory    = 3
+	anomalyMinPeriods         = 3
+)
+
+type Anomaly struct {
+	Type          string  `json:"type"`
+	TransactionID *uint   `json:"transaction_id,omitempty"`
+	Date          string  `json:"date"`
+	CategoryID    uint    `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	Payee         string  `json:"payee,omitempty"`
+	Amount        float64 `json:"amount"`
+	Baseline      float64 `json:"baseline"`
+	Score         float64 `json:"score"`
+	Message       string  `json:"message"`
+}
+
+// anomalyTx is an expense as seen by the detector, with a positive amount
+type anomalyTx struct {
+	ID           uint
+	Date         time.Time
+	Amount       float64
+	CategoryID   uint
+	CategoryName string
+	Payee        string
+}
+
+// payeeKey groups transactions by payee, falling back to the description
+func payeeKey(payee, description string) string {
+	key := strings.ToLower(strings.TrimSpace(payee))
+	if key == "" {
+		key = strings.ToLower(strings.TrimSpace(description))
+	}
+	return key
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// robustZ returns the modified z-score of x against history and the history
+// median. The median absolute deviation is floored at 1% of the median so that
+// perfectly regular amounts (subscriptions) still flag changes of a few percent.
+func robustZ(history []float64, x float64) (float64, float64) {
+	med := median(history)
+	deviations := make([]float64, len(history))
+	for i, v := range history {
+		deviations[i] = math.Abs(v - med)
+	}
+	mad := math.Max(median(deviations), 0.01*math.Abs(med))
+	if mad == 0 {
+		return 0, med
+	}
+	return 0.6745 * (x - med) / mad, med
+}
+
+// findAnomalies flags expenses in [from, to) against baselines computed from
+// the earlier transactions in txs.
+func findAnomalies(txs []anomalyTx, from, to time.Time) []Anomaly {
+	byCategory := make(map[uint][]float64)
+	byPayee := make(map[string][]float64)
+	names := make(map[uint]string)
+	var window []anomalyTx
+	for _, t := range txs {
+		names[t.CategoryID] = t.CategoryName
+		if !t.Date.Before(from) {
+			if t.Date.Before(to) {
+				window = append(window, t)
+			}
+			continue
+		}
+		byCategory[t.CategoryID] = append(byCategory[t.CategoryID], t.Amount)
+		if t.Payee != "" {
+			byPayee[t.Payee] = append(byPayee[t.Payee], t.Amount)
+		}
+	}
+
+	anomalies := []Anomaly{}
+	for _, t := range window {
+		id := t.ID
+		a := Anomaly{TransactionID: &id, Date: t.Date.Format("2006-01-02"), CategoryID: t.CategoryID, CategoryName: t.CategoryName, Payee: t.Payee, Amount: -t.Amount}
+		if history := byPayee[t.Payee]; t.Payee != "" && len(history) >= anomalyMinPayeeHistory {
+			if z, med := robustZ(history, t.Amount); math.Abs(z) > anomalyRobustZ {
+				a.Type, a.Baseline, a.Score = AnomalyPayeeAmount, -med, math.Abs(z)
+				direction := "higher"
+				if z < 0 {
+					direction = "lower"
+				}
+				a.Message = fmt.Sprintf("%s charged %.2f, %s than the usual %.2f", t.Payee, t.Amount, direction, med)
+				anomalies = append(anomalies, a)
+				continue
+			}
+		}
+		if history := byCategory[t.CategoryID]; len(history) >= anomalyMinCategoryHistory {
+			if z, med := robustZ(history, t.Amount); z > anomalyRobustZ {
+				a.Type, a.Baseline, a.Score = AnomalyLargeTransaction, -med, z
+				a.Message = fmt.Sprintf("%.2f in %s is unusually large (typical %.2f)", t.Amount, t.CategoryName, med)
+				anomalies = append(anomalies, a)
+			}
+		}
+	}
+
+	// category totals of the window against the totals of earlier periods of the same length
+	length := to.Sub(from)
+	periods := int(time.Duration(anomalyHistoryDays) * 24 * time.Hour / length)
+	if periods > 12 {
+		periods = 12
+	}
+	if periods >= anomalyMinPeriods {
+		current := make(map[uint]float64)
+		past := make(map[uint][]float64)
+		for _, t := range window {
+			current[t.CategoryID] += t.Amount
+		}
+		for _, t := range txs {
+			if !t.Date.Before(from) {
+				continue
+			}
+			// period k covers [from-(k+1)*length, from-k*length)
+			if k := int((from.Sub(t.Date) - 1) / length); k < periods {
+				if past[t.CategoryID] == nil {
+					past[t.CategoryID] = make([]float64, periods)
+				}
+				past[t.CategoryID][k] += t.Amount
+			}
+		}
+		for catID, total := range current {
+			mean, stddev := meanStdDev(past[catID])
+			if past[catID] == nil || stddev == 0 {
+				continue
+			}
+			if z := (total - mean) / stddev; z > anomalySpikeZ {
+				anomalies = append(anomalies, Anomaly{
+					Type:         AnomalyCategorySpike,
+					Date:         to.AddDate(0, 0, -1).Format("2006-01-02"),
+					CategoryID:   catID,
+					CategoryName: names[catID],
+					Amount:       -total,
+					Baseline:     -mean,
+					Score:        z,
+					Message:      fmt.Sprintf("Spending in %s is %.2f, above the usual %.2f", names[catID], total, mean),
+				})
+			}
+		}
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].Score > anomalies[j].Score })
+	return anomalies
+}
+
+// detectAnomalies loads the user's expenses outside transfer categories and
+// flags the unusual ones in [from, to).
+func detectAnomalies(userID uint, from, to time.Time) ([]Anomaly, error) {
+	rows, err := config.DB.Table("transactions t").
+		Select("t.id, t.date, t.amount, t.category_id, c.name, COALESCE(t.payee, ''), COALESCE(t.description, '')").
+		Joins("JOIN categories c ON t.category_id = c.id").
+		Where("t.user_id = ? AND t.deleted_at IS NULL AND t.amount < 0 AND COALESCE(c.kind, '') <> ?", userID, models.CategoryKindTransfer).
+		Where("t.date >= ? AND t.date < ?", from.AddDate(0, 0, -anomalyHistoryDays), to).
+		Order("t.date").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var txs []anomalyTx
+	for rows.Next() {
+		var t anomalyTx
+		var payee, description string
+		if err := rows.Scan(&t.ID, &t.Date, &t.Amount, &t.CategoryID, &t.CategoryName, &payee, &description); err != nil {
+			return nil, err
+		}
+		t.Date = t.Date.UTC()
+		t.Amount = -t.Amount
+		t.Payee = payeeKey(payee, description)
+		txs = append(txs, t)
+	}
+	return findAnomalies(txs, from, to), nil
+}
+
+// anomalyKey identifies an anomaly so it is pushed only once
+func anomalyKey(a Anomaly) string {
+	if a.TransactionID != nil {
+		return fmt.Sprintf("%s:%d", a.Type, *a.TransactionID)
+	}
+	return fmt.Sprintf("%s:%d:%s", a.Type, a.CategoryID, a.Date[:7])
+}
+
+// PushAnomalyNotifications notifies users who enabled anomaly alerts about
+// anomalies in the last 30 days that they were not told about yet. A failure
+// for one user is logged and does not hold up the others.
+func PushAnomalyNotifications(now time.Time) error {
+	var users []models.User
+	if err := config.DB.Where("anomaly_alerts = ?", true).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		y, m, d := now.In(loc).Date()
+		to := time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+		anomalies, err := detectAnomalies(user.ID, to.AddDate(0, 0, -30), to)
+		if err != nil {
+			log.Printf("failed to detect anomalies for user %d: %v", user.ID, err)
+			continue
+		}
+		for _, a := range anomalies {
+			alert := models.AnomalyAlert{UserID: user.ID, Key: anomalyKey(a)}
+			result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+			if result.Error != nil {
+				log.Printf("failed to record anomaly alert for user %d: %v", user.ID, result.Error)
+			}
+			if result.Error != nil || result.RowsAffected == 0 {
+				continue
+			}
+			err := notify.Send(config.DB, notify.Message{UserID: user.ID, Kind: "anomaly." + a.Type, Title: "Unusual spending detected", Body: a.Message, Data: a})
+			if err != nil {
+				log.Printf("failed to send anomaly alert to user %d: %v", user.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetAnomalyReport returns unusual spending of the authenticated user
+// @Summary Spending anomalies
+// @Description Flags unusual expenses in the date range against baselines from the preceding year: transactions far from the usual amount for their payee (e.g. a subscription price rise), unusually large transactions for their category (median absolute deviation), and categories whose total is well above the totals of earlier periods of the same length (z-score). Payees fall back to the description when unset.
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param start_date query string false "Start date (YYYY-MM-DD, default: 30 days before end_date)"
+// @Param end_date query string false "End date (YYYY-MM-DD, default: today)"
+// @Param tz query string false "IANA timezone (default: the user's timezone)"
+// @Success 200 {array} Anomaly
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/anomalies [get]
+func GetAnomalyReport(c *gin.Context) {
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to := today(loc).AddDate(0, 0, 1)
+	if end := c.Query("end_date"); end != "" {
+		v, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD."})
+			return
+		}
+		to = v.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -30)
+	if start := c.Query("start_date"); start != "" {
+		if from, err = time.Parse("2006-01-02", start); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD."})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must not be after end_date"})
+		return
+	}
+	anomalies, err := detectAnomalies(c.GetUint("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, anomalies)
+}
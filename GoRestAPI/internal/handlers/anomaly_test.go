@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobustZ(t *testing.T) {
+	z, med := robustZ([]float64{10, 12, 11, 13, 9}, 40)
+	assert.Equal(t, 11.0, med)
+	assert.Greater(t, z, anomalyRobustZ)
+
+	// identical subscription charges still flag a price rise
+	z, _ = robustZ([]float64{9.99, 9.99, 9.99}, 12.99)
+	assert.Greater(t, z, anomalyRobustZ)
+	z, _ = robustZ([]float64{9.99, 9.99, 9.99}, 9.99)
+	assert.Zero(t, z)
+}
+
+func TestFindAnomalies(t *testing.T) {
+	d := func(s string) time.Time {
+		v, _ := time.Parse("2006-01-02", s)
+		return v
+	}
+	var txs []anomalyTx
+	id := uint(1)
+	add := func(date string, amount float64, cat uint, name, payee string) {
+		txs = append(txs, anomalyTx{ID: id, Date: d(date), Amount: amount, CategoryID: cat, CategoryName: name, Payee: payee})
+		id++
+	}
+	for _, m := range []string{"01", "02", "03", "04", "05", "06"} {
+		add("2025-"+m+"-05", 15.99, 1, "Entertainment", "streamflix")
+		add("2025-"+m+"-10", 40, 2, "Groceries", "market")
+		add("2025-"+m+"-20", 45, 2, "Groceries", "market")
+		add("2025-"+m+"-12", 20, 3, "Restaurants", "")
+	}
+	add("2025-07-05", 19.99, 1, "Entertainment", "streamflix")
+	add("2025-07-10", 42, 2, "Groceries", "market")
+	add("2025-07-15", 400, 2, "Groceries", "")
+	add("2025-07-12", 20, 3, "Restaurants", "")
+
+	anomalies := findAnomalies(txs, d("2025-07-01"), d("2025-08-01"))
+	types := make(map[string]uint)
+	for _, a := range anomalies {
+		types[a.Type] = a.CategoryID
+	}
+	assert.Len(t, anomalies, 3)
+	assert.Equal(t, uint(1), types[AnomalyPayeeAmount])
+	assert.Equal(t, uint(2), types[AnomalyLargeTransaction])
+	assert.Equal(t, uint(2), types[AnomalyCategorySpike])
+	assert.Equal(t, -400.0, anomalies[0].Amount)
+}
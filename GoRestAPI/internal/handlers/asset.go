@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+type AssetInput struct {
+	Name string `json:"name" binding:"required"`
+	Kind string `json:"kind" binding:"required,oneof=asset liability"`
+}
+
+type ValuationInput struct {
+	Date  string  `json:"date" binding:"required"`
+	Value float64 `json:"value" binding:"gte=0"`
+}
+
+type AssetValue struct {
+	models.Asset
+	Value         float64    `json:"value"`
+	ValuationDate *time.Time `json:"valuation_date"`
+}
+
+// findAsset loads an asset of the user from the :id path parameter
+func findAsset(c *gin.Context) (models.Asset, bool) {
+	var asset models.Asset
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&asset).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		return asset, false
+	}
+	return asset, true
+}
+
+// ListAssets returns the assets and liabilities of the authenticated user with their latest value
+// @Summary List assets
+// @Description Get the manually tracked assets and liabilities of the current user with their latest valuation
+// @Tags assets
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} AssetValue
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /assets [get]
+func ListAssets(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var assets []models.Asset
+	if err := config.DB.Where("user_id = ?", userID).Order("kind, name").Find(&assets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	list := make([]AssetValue, 0, len(assets))
+	for _, a := range assets {
+		item := AssetValue{Asset: a}
+		var v models.AssetValuation
+		if config.DB.Where("asset_id = ?", a.ID).Order("date DESC").First(&v).Error == nil {
+			item.Value = v.Value
+			item.ValuationDate = &v.Date
+		}
+		list = append(list, item)
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateAsset creates an asset or liability for the authenticated user
+// @Summary Create asset
+// @Description Create a manually tracked asset (house, car, investments) or liability (loan, mortgage)
+// @Tags assets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body AssetInput true "Asset info"
+// @Success 201 {object} models.Asset
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /assets [post]
+func CreateAsset(c *gin.Context) {
+	var input AssetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	asset := models.Asset{UserID: c.GetUint("user_id"), Name: input.Name, Kind: input.Kind}
+	if err := config.DB.Create(&asset).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, asset)
+}
+
+// UpdateAsset updates an asset or liability for the authenticated user
+// @Summary Update asset
+// @Description Rename an asset or change whether it is an asset or a liability
+// @Tags assets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param input body AssetInput true "Asset info"
+// @Success 200 {object} models.Asset
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /assets/{id} [put]
+func UpdateAsset(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	var input AssetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	asset.Name = input.Name
+	asset.Kind = input.Kind
+	config.DB.Save(&asset)
+	c.JSON(http.StatusOK, asset)
+}
+
+// DeleteAsset deletes an asset or liability and its valuations
+// @Summary Delete asset
+// @Description Delete an asset or liability of the current user with all its valuations. Recorded net worth snapshots are kept.
+// @Tags assets
+// @Security BearerAuth
+// @Param id path int true "Asset ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /assets/{id} [delete]
+func DeleteAsset(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	config.DB.Where("asset_id = ?", asset.ID).Delete(&models.AssetValuation{})
+	if err := config.DB.Delete(&asset).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListValuations returns the valuation history of an asset
+// @Summary List asset valuations
+// @Description Get the dated valuations of an asset or liability, newest first
+// @Tags assets
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {array} models.AssetValuation
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /assets/{id}/valuations [get]
+func ListValuations(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	var list []models.AssetValuation
+	config.DB.Where("asset_id = ?", asset.ID).Order("date DESC").Find(&list)
+	c.JSON(http.StatusOK, list)
+}
+
+// SetValuation records the value of an asset on a date
+// @Summary Set asset valuation
+// @Description Record the value of an asset, or the outstanding amount of a liability, as of a date. A valuation on the same date is replaced.
+// @Tags assets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param input body ValuationInput true "Valuation"
+// @Success 200 {object} models.AssetValuation
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /assets/{id}/valuations [post]
+func SetValuation(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	var input ValuationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD."})
+		return
+	}
+	v := models.AssetValuation{AssetID: asset.ID, Date: date, Value: input.Value}
+	err = config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "asset_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+	}).Create(&v).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	config.DB.Where("asset_id = ? AND date = ?", asset.ID, date).First(&v)
+	c.JSON(http.StatusOK, v)
+}
+
+// DeleteValuation deletes a valuation of an asset
+// @Summary Delete asset valuation
+// @Description Delete a dated valuation of an asset or liability
+// @Tags assets
+// @Security BearerAuth
+// @Param id path int true "Asset ID"
+// @Param valuation_id path int true "Valuation ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /assets/{id}/valuations/{valuation_id} [delete]
+func DeleteValuation(c *gin.Context) {
+	asset, ok := findAsset(c)
+	if !ok {
+		return
+	}
+	id, _ := strconv.Atoi(c.Param("valuation_id"))
+	if err := config.DB.Where("id = ? AND asset_id = ?", id, asset.ID).Delete(&models.AssetValuation{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+type RevertInput struct {
+	// Version is the version of the transaction to go back to, as listed in its history
+	Version uint `json:"version" binding:"required"`
+}
+
+// AuditPage is one page of the audit log, newest entries first
+type AuditPage struct {
+	Data []models.AuditEntry `json:"data"`
+	// NextBeforeID fetches the following page as before_id; it is null on the last page
+	NextBeforeID *uint `json:"next_before_id"`
+}
+
+// GetTransactionHistory returns the audit entries of a transaction of the authenticated user
+// @Summary Transaction history
+// @Description Get every recorded change to a transaction, oldest first, with the actor, IP, the transaction before and after and the changed fields. Trashed transactions keep their history.
+// @Tags transactions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Success 200 {array} models.AuditEntry
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /transactions/{id}/history [get]
+func GetTransactionHistory(c *gin.Context) {
+	var tx models.Transaction
+	if err := config.DB.Unscoped().Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&tx).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	entries := []models.AuditEntry{}
+	err := config.DB.Where("entity_type = ? AND entity_id = ?", audit.EntityTransaction, tx.ID).Order("id").Find(&entries).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// RevertTransaction sets a transaction of the authenticated user back to a previous version
+// @Summary Revert transaction
+// @Description Restore the amount, date, category, account, payee, description, notes and tags a transaction had at a version from its history. The revert is a new version and is itself recorded.
+// @Tags transactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Param input body RevertInput true "Version to go back to"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 412 {object} gin.H{"error":string}
+// @Router /transactions/{id}/revert [post]
+func RevertTransaction(c *gin.Context) {
+	var tx models.Transaction
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&tx).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if !ifMatch(c, tx.Version) {
+		return
+	}
+	var input RevertInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Version == tx.Version {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The transaction is already at this version"})
+		return
+	}
+	var entry models.AuditEntry
+	err := config.DB.Where("entity_type = ? AND entity_id = ? AND version = ? AND after IS NOT NULL", audit.EntityTransaction, tx.ID, input.Version).
+		Order("id DESC").First(&entry).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found in the transaction's history"})
+		return
+	}
+	old, err := revertInput(entry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	updateTransaction(c, audit.ActionRevert, tx, old)
+}
+
+// revertInput returns the input that puts a transaction back into the state
+// recorded after an audit entry
+func revertInput(entry models.AuditEntry) (TransactionInput, error) {
+	var old models.Transaction
+	if err := json.Unmarshal(entry.After, &old); err != nil {
+		return TransactionInput{}, err
+	}
+	input := transactionInput(old)
+	if input.Tags == nil {
+		input.Tags = []string{}
+	}
+	return input, nil
+}
+
+// ListAuditLog searches the audit log
+// @Summary Search audit log
+// @Description Search the audit entries of all users, newest first. Admin only.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query int false "User who made the change"
+// @Param entity_type query string false "transaction, category or user"
+// @Param entity_id query int false "ID of the changed entity"
+// @Param action query string false "Action, e.g. update or login_failed"
+// @Param ip query string false "Client IP"
+// @Param from query string false "Earliest time (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Latest time, exclusive (YYYY-MM-DD or RFC 3339)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param before_id query int false "Return entries older than this ID"
+// @Success 200 {object} AuditPage
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 403 {object} gin.H{"error":string}
+// @Router /admin/audit [get]
+func ListAuditLog(c *gin.Context) {
+	query := config.DB.Model(&models.AuditEntry{})
+	for _, param := range []string{"actor_id", "entity_id", "before_id"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return
+		}
+		if param == "before_id" {
+			query = query.Where("id < ?", id)
+		} else {
+			query = query.Where(param+" = ?", id)
+		}
+	}
+	for _, param := range []string{"entity_type", "action", "ip"} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(param+" = ?", value)
+		}
+	}
+	for _, param := range []string{"from", "to"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := parseAuditTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + "; use YYYY-MM-DD or RFC 3339"})
+			return
+		}
+		if param == "from" {
+			query = query.Where("created_at >= ?", t)
+		} else {
+			query = query.Where("created_at < ?", t)
+		}
+	}
+	limit := defaultAuditPageSize
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAuditPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+	page := AuditPage{Data: []models.AuditEntry{}}
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&page.Data).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(page.Data) > limit {
+		page.Data = page.Data[:limit]
+		next := page.Data[limit-1].ID
+		page.NextBeforeID = &next
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// parseAuditTime parses a date, as UTC midnight, or an RFC 3339 time
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, errors.New("invalid time")
+	}
+	return t.UTC(), nil
+}
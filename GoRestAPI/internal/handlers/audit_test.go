@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevertInput(t *testing.T) {
+	entry := models.AuditEntry{After: []byte(`{"id":2,"amount":-9,"date":"2025-07-05T00:00:00Z","category_id":6,"account_id":4,"user_id":3,"payee":"Pizza","description":"","notes":"n","tags":["dinner"]}`)}
+	in, err := revertInput(entry)
+	assert.NoError(t, err)
+	account := uint(4)
+	assert.Equal(t, TransactionInput{Amount: -9, Date: "2025-07-05", CategoryID: 6, AccountID: &account, Payee: "Pizza", Notes: "n", Tags: []string{"dinner"}}, in)
+
+	// a version without tags clears the current ones
+	in, err = revertInput(models.AuditEntry{After: []byte(`{"amount":-9,"date":"2025-07-05T00:00:00Z","category_id":6}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, in.Tags)
+	assert.Nil(t, in.AccountID)
+}
+
+func TestParseAuditTime(t *testing.T) {
+	d, err := parseAuditTime("2025-07-05")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 5, 0, 0, 0, 0, time.UTC), d)
+	d, err = parseAuditTime("2025-07-05T10:00:00+02:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 5, 8, 0, 0, 0, time.UTC), d)
+	_, err = parseAuditTime("yesterday")
+	assert.Error(t, err)
+}
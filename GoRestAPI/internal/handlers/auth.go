@@ -1,19 +1,31 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"expense-tracker/internal/models"
 	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/templates"
 	"expense-tracker/pkg/auth"
 	"golang.org/x/crypto/bcrypt"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type RegisterInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
+	// Template is the category template to start with; "none" skips it
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
 }
 
+// errEmailTaken is returned when registering an email that already has an account
+var errEmailTaken = errors.New("Email already registered")
+
 type LoginInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -21,13 +33,14 @@ type LoginInput struct {
 
 // Register creates a new user account
 // @Summary Register a new user
-// @Description Create a new user account with email and password
+// @Description Create a new user account with email and password, seeded with a category template (default: "default"). If the template cannot be applied, no account is created.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body RegisterInput true "User registration info"
 // @Success 201 {object} gin.H{"message":string}
 // @Failure 400 {object} gin.H{"error":string}
+// @Failure 500 {object} gin.H{"error":string}
 // @Router /auth/register [post]
 func Register(c *gin.Context) {
 	var input RegisterInput
@@ -35,16 +48,47 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	var tmpl *templates.Template
+	if input.Template != "none" {
+		key := input.Template
+		if key == "" {
+			key = templates.DefaultKey
+		}
+		t, err := templates.Find(config.DB, key, input.Locale)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown category template"})
+			return
+		}
+		tmpl = &t
+	}
 	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
 	user := models.User{Email: input.Email, PasswordHash: string(hash)}
-	if err := config.DB.Create(&user).Error; err != nil {
+	err = config.DB.Transaction(func(db *gorm.DB) error {
+		if err := db.Create(&user).Error; err != nil {
+			return errEmailTaken
+		}
+		actor := audit.Actor{UserID: user.ID, IP: c.ClientIP()}
+		if err := audit.Record(db, actor, audit.ActionRegister, audit.EntityUser, user.ID, 0, nil, profileOf(user)); err != nil {
+			return err
+		}
+		if tmpl == nil {
+			return nil
+		}
+		_, err := applyTemplate(db, actor, user.ID, *tmpl)
+		return err
+	})
+	if errors.Is(err, errEmailTaken) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already registered"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{"message": "Registration successful"})
 }
 
@@ -58,6 +102,7 @@ func Register(c *gin.Context) {
 // @Success 200 {object} gin.H{"token":string}
 // @Failure 400 {object} gin.H{"error":string}
 // @Failure 401 {object} gin.H{"error":string}
+// @Failure 500 {object} gin.H{"error":string}
 // @Router /auth/login [post]
 func Login(c *gin.Context) {
 	var input LoginInput
@@ -66,11 +111,18 @@ func Login(c *gin.Context) {
 		return
 	}
 	var user models.User
-	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	err := config.DB.Where("email = ?", input.Email).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up user"})
 		return
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)) != nil {
+		var details interface{}
+		if user.ID == 0 {
+			// an unknown email is only kept masked, enough to spot repeated attempts
+			details = gin.H{"email": maskEmail(input.Email)}
+		}
+		recordLogin(c, audit.Actor{IP: c.ClientIP()}, audit.ActionLoginFailed, user.ID, details)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -79,5 +131,24 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	recordLogin(c, audit.Actor{UserID: user.ID, IP: c.ClientIP()}, audit.ActionLogin, user.ID, nil)
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// maskEmail hides all but the first character of the local part of an email,
+// e.g. "j***@example.com"
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return "***"
+	}
+	return string([]rune(email)[:1]) + "***" + email[at:]
+}
+
+// recordLogin appends a login attempt to the audit log; a failure to record
+// it does not fail the login
+func recordLogin(c *gin.Context, actor audit.Actor, action string, userID uint, details interface{}) {
+	if err := audit.Record(config.DB, actor, action, audit.EntityUser, userID, 0, nil, details); err != nil {
+		log.Printf("failed to record %s of user %d: %v", action, userID, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"expense-tracker/internal/models"
 	"expense-tracker/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/gin-gonic/gin"
@@ -36,3 +37,61 @@ func TestLogin(t *testing.T) {
 	_, ok := resp["token"]
 	assert.True(t, ok, "token should be present in response")
 }
+
+func TestRegisterAppliesTemplate(t *testing.T) {
+	setupTestDB(t)
+	w := serve(0, Register, http.MethodPost, "/auth/register", "/auth/register", `{"email":"new@example.com","password":"secret1","locale":"en"}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var user models.User
+	assert.NoError(t, config.DB.Where("email = ?", "new@example.com").First(&user).Error)
+	var n int64
+	config.DB.Model(&models.Category{}).Where("user_id = ?", user.ID).Count(&n)
+	assert.NotZero(t, n)
+	var created int64
+	config.DB.Model(&models.AuditEntry{}).Where("actor_id = ? AND entity_type = ? AND action = ?", user.ID, "category", "create").Count(&created)
+	assert.Equal(t, n, created)
+
+	w = serve(0, Register, http.MethodPost, "/auth/register", "/auth/register", `{"email":"none@example.com","password":"secret1","template":"none"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var bare models.User
+	config.DB.Where("email = ?", "none@example.com").First(&bare)
+	config.DB.Model(&models.Category{}).Where("user_id = ?", bare.ID).Count(&n)
+	assert.Zero(t, n)
+
+	w = serve(0, Register, http.MethodPost, "/auth/register", "/auth/register", `{"email":"bad@example.com","password":"secret1","template":"nope"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	config.DB.Model(&models.User{}).Where("email = ?", "bad@example.com").Count(&n)
+	assert.Zero(t, n)
+}
+
+func TestLoginFailures(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "known@example.com")
+	login := func(email string) int {
+		return serve(0, Login, http.MethodPost, "/auth/login", "/auth/login", `{"email":"`+email+`","password":"wrong"}`).Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("known@example.com"))
+	assert.Equal(t, http.StatusUnauthorized, login("jane@example.com"))
+	var entries []models.AuditEntry
+	config.DB.Where("action = ?", "login_failed").Order("id").Find(&entries)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, user.ID, entries[0].EntityID)
+		assert.Empty(t, entries[0].After)
+		assert.Zero(t, entries[1].EntityID)
+		assert.JSONEq(t, `{"email":"j***@example.com"}`, string(entries[1].After))
+	}
+
+	// a database failure is not reported as bad credentials
+	db, _ := config.DB.DB()
+	db.Close()
+	assert.Equal(t, http.StatusInternalServerError, login("known@example.com"))
+}
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "j***@example.com", maskEmail("jane@example.com"))
+	assert.Equal(t, "é***@example.com", maskEmail("élodie@example.com"))
+	assert.Equal(t, "***", maskEmail("@example.com"))
+	assert.Equal(t, "***", maskEmail("nobody"))
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/notify"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// budgetAlertThresholds are the spending percentages, in ascending order, that trigger a notification
+var budgetAlertThresholds = []int{80, 100}
+
+type BudgetInput struct {
+	CategoryID uint    `json:"category_id" binding:"required"`
+	Period     string  `json:"period" binding:"required,oneof=weekly monthly yearly custom"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	Rollover   bool    `json:"rollover"`
+	StartDate  string  `json:"start_date"`
+	EndDate    string  `json:"end_date"`
+}
+
+// apply validates the input and copies it onto a budget. An omitted
+// start_date keeps the start of an existing budget, so that its rollover
+// history is not lost, and defaults to the current period for a new one.
+// Custom budgets require both dates.
+func (in BudgetInput) apply(userID uint, b *models.Budget) error {
+	var cat models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", in.CategoryID, userID).First(&cat).Error; err != nil {
+		return errors.New("Category not found")
+	}
+	if cat.Kind == models.CategoryKindIncome {
+		return errors.New("Budgets can only be set on expense categories")
+	}
+	b.CategoryID = in.CategoryID
+	b.Period = in.Period
+	b.Amount = in.Amount
+	b.Rollover = in.Rollover
+	b.EndDate = nil
+	switch {
+	case in.StartDate == "" && in.Period == models.BudgetPeriodCustom:
+		return errors.New("Custom budgets require a start_date (YYYY-MM-DD)")
+	case in.StartDate == "" && b.ID == 0:
+		b.StartDate, _ = b.PeriodAt(time.Now().UTC())
+	case in.StartDate != "":
+		start, err := time.Parse("2006-01-02", in.StartDate)
+		if err != nil {
+			return errors.New("Invalid start_date format. Use YYYY-MM-DD.")
+		}
+		b.StartDate = start
+	}
+	if in.Period == models.BudgetPeriodCustom {
+		end, err := time.Parse("2006-01-02", in.EndDate)
+		if err != nil || end.Before(b.StartDate) {
+			return errors.New("Custom budgets require an end_date (YYYY-MM-DD) on or after start_date")
+		}
+		b.EndDate = &end
+	}
+	return nil
+}
+
+// ListBudgets returns all budgets for the authenticated user
+// @Summary List budgets
+// @Description Get all budgets for the current user
+// @Tags budgets
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Budget
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /budgets [get]
+func ListBudgets(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var budgets []models.Budget
+	if err := config.DB.Where("user_id = ?", userID).Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, budgets)
+}
+
+// CreateBudget creates a new budget for the authenticated user
+// @Summary Create budget
+// @Description Create a budget for a category (or category group) and period. start_date defaults to the current period.
+// @Tags budgets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body BudgetInput true "Budget info"
+// @Success 201 {object} models.Budget
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /budgets [post]
+func CreateBudget(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input BudgetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget := models.Budget{UserID: userID}
+	if err := input.apply(userID, &budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Create(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, budget)
+}
+
+// GetBudget returns a budget by ID for the authenticated user
+// @Summary Get budget
+// @Description Get a budget by ID for the current user
+// @Tags budgets
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} models.Budget
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /budgets/{id} [get]
+func GetBudget(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	var budget models.Budget
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget updates a budget for the authenticated user
+// @Summary Update budget
+// @Description Update a budget for the current user. An omitted start_date keeps the current one.
+// @Tags budgets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Param input body BudgetInput true "Budget info"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /budgets/{id} [put]
+func UpdateBudget(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	var budget models.Budget
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+	var input BudgetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.apply(userID, &budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Save(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget deletes a budget for the authenticated user
+// @Summary Delete budget
+// @Description Delete a budget for the current user
+// @Tags budgets
+// @Security BearerAuth
+// @Param id path int true "Budget ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /budgets/{id} [delete]
+func DeleteBudget(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Budget{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// checkBudgetAlerts evaluates the budgets covering a category on the given date
+// and notifies the user of every threshold crossed for the first time in that
+// budget period. It is called after transactions are written.
+func checkBudgetAlerts(userID, categoryID uint, date time.Time) {
+	var cat models.Category
+	if err := config.DB.Unscoped().First(&cat, categoryID).Error; err != nil {
+		return
+	}
+	ids := []uint{cat.ID}
+	if cat.ParentID != nil {
+		ids = append(ids, *cat.ParentID)
+	}
+	var budgets []models.Budget
+	if err := config.DB.Where("user_id = ? AND category_id IN ?", userID, ids).Find(&budgets).Error; err != nil {
+		log.Printf("failed to load budgets for alerts: %v", err)
+		return
+	}
+	for _, b := range budgets {
+		status, ok := budgetStatus(b, date, date.AddDate(0, 0, 1))
+		if !ok {
+			continue
+		}
+		for _, threshold := range budgetAlertThresholds {
+			if status.Percentage < float64(threshold) {
+				break
+			}
+			alert := models.BudgetAlert{BudgetID: b.ID, Threshold: threshold, PeriodStart: status.StartDate, Percentage: status.Percentage}
+			result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+			if result.Error != nil || result.RowsAffected == 0 {
+				continue
+			}
+			title := budgetAlertTitle(status, threshold)
+			body := fmt.Sprintf("You have spent %.2f of %.2f (%.0f%%) for %s to %s.",
+				status.Spent, status.Budgeted+status.Rollover, status.Percentage,
+				status.StartDate.Format("2006-01-02"), status.EndDate.Format("2006-01-02"))
+			err := notify.Send(config.DB, notify.Message{UserID: userID, Kind: "budget.threshold", Title: title, Body: body, Data: gin.H{
+				"budget_id": b.ID,
+				"threshold": threshold,
+				"status":    status,
+			}})
+			if err != nil {
+				log.Printf("failed to send budget alert for budget %d: %v", b.ID, err)
+			}
+		}
+	}
+}
+
+// budgetAlertTitle names the threshold an alert is for. Only the 100% alert
+// says the budget is exceeded, so a transaction that jumps past both
+// thresholds still sends a warning first.
+func budgetAlertTitle(status BudgetStatus, threshold int) string {
+	if threshold >= 100 && status.Remaining < 0 {
+		return fmt.Sprintf("%s budget exceeded", status.CategoryName)
+	}
+	if threshold < 100 {
+		return fmt.Sprintf("Warning: %s budget at %d%%", status.CategoryName, threshold)
+	}
+	return fmt.Sprintf("%s budget at %d%%", status.CategoryName, threshold)
+}
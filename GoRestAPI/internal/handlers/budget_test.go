@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetInputApply(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "budgets@example.com")
+	cat := models.Category{UserID: user.ID, Name: "Groceries", Kind: models.CategoryKindExpense}
+	config.DB.Create(&cat)
+
+	var b models.Budget
+	err := BudgetInput{CategoryID: cat.ID, Period: models.BudgetPeriodCustom, Amount: 100, EndDate: "2025-08-31"}.apply(user.ID, &b)
+	assert.Error(t, err, "custom budgets need a start_date")
+
+	b = models.Budget{UserID: user.ID}
+	assert.NoError(t, BudgetInput{CategoryID: cat.ID, Period: models.BudgetPeriodMonthly, Amount: 100}.apply(user.ID, &b))
+	start, _ := b.PeriodAt(time.Now().UTC())
+	assert.Equal(t, start, b.StartDate, "new budgets start in the current period")
+
+	b = models.Budget{ID: 1, UserID: user.ID, StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, BudgetInput{CategoryID: cat.ID, Period: models.BudgetPeriodMonthly, Amount: 150, Rollover: true}.apply(user.ID, &b))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), b.StartDate, "updates keep the start")
+	assert.NoError(t, BudgetInput{CategoryID: cat.ID, Period: models.BudgetPeriodCustom, Amount: 150, StartDate: "2025-08-01", EndDate: "2025-08-31"}.apply(user.ID, &b))
+	assert.Equal(t, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), b.StartDate)
+}
+
+func TestBudgetAlertTitle(t *testing.T) {
+	over := BudgetStatus{CategoryName: "Groceries", Spent: 130, Budgeted: 100, Remaining: -30, Percentage: 130}
+	assert.Equal(t, "Warning: Groceries budget at 80%", budgetAlertTitle(over, 80))
+	assert.Equal(t, "Groceries budget exceeded", budgetAlertTitle(over, 100))
+
+	exact := BudgetStatus{CategoryName: "Groceries", Spent: 100, Budgeted: 100, Percentage: 100}
+	assert.Equal(t, "Groceries budget at 100%", budgetAlertTitle(exact, 100))
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/filter"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBulkItems bounds how many transactions one bulk request may touch
+const maxBulkItems = 1000
+
+// TransactionPatch holds the fields a bulk update changes; omitted fields are
+// kept and an account_id of null clears the account
+type TransactionPatch struct {
+	Amount      *float64     `json:"amount"`
+	Date        *string      `json:"date"`
+	CategoryID  *uint        `json:"category_id"`
+	AccountID   nullableUint `json:"account_id" swaggertype:"integer"`
+	Payee       *string      `json:"payee"`
+	Description *string      `json:"description"`
+	Notes       *string      `json:"notes"`
+	Tags        []string     `json:"tags"`
+}
+
+// BulkOperation is one step of a bulk request. create takes transaction;
+// update, delete, recategorize and tag apply to the transactions listed in
+// ids or matching filter.
+type BulkOperation struct {
+	Op          string            `json:"op" binding:"required,oneof=create update delete recategorize tag"`
+	IDs         []uint            `json:"ids"`
+	Filter      string            `json:"filter"`
+	Transaction *TransactionInput `json:"transaction"`
+	// Set is the change of an update
+	Set *TransactionPatch `json:"set"`
+	// CategoryID is the new category of a recategorize
+	CategoryID uint `json:"category_id"`
+	// Add and Remove are the tags changed by a tag operation
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type BulkRequest struct {
+	// Atomic rolls back every operation when any item fails
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BulkResult is the outcome for one transaction of one operation
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     uint   `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Committed bool         `json:"committed"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// bulkError is an item failure caused by the request rather than the database
+type bulkError struct {
+	status int
+	err    error
+}
+
+func (e *bulkError) Error() string { return e.err.Error() }
+
+func invalidItem(err error) error { return &bulkError{http.StatusBadRequest, err} }
+
+// errRollback aborts an atomic bulk request after an item failed
+var errRollback = errors.New("bulk request rolled back")
+
+// validate checks that an operation has what it needs
+func (op BulkOperation) validate() error {
+	if op.Op == "create" {
+		if op.Transaction == nil {
+			return errors.New("create needs a transaction")
+		}
+		return nil
+	}
+	if (len(op.IDs) > 0) == (op.Filter != "") {
+		return fmt.Errorf("%s needs either ids or filter", op.Op)
+	}
+	switch {
+	case op.Op == "update" && op.Set == nil:
+		return errors.New("update needs set")
+	case op.Op == "recategorize" && op.CategoryID == 0:
+		return errors.New("recategorize needs category_id")
+	case op.Op == "tag" && len(op.Add) == 0 && len(op.Remove) == 0:
+		return errors.New("tag needs add or remove")
+	}
+	return nil
+}
+
+// targets returns the IDs an operation applies to. Listed IDs are returned
+// as given, so that missing ones are reported per item.
+func (op BulkOperation) targets(userID uint, today time.Time) ([]uint, error) {
+	if op.Op == "create" {
+		return []uint{0}, nil
+	}
+	if op.Filter == "" {
+		return op.IDs, nil
+	}
+	node, err := filter.Parse(op.Filter)
+	if err != nil {
+		return nil, err
+	}
+	cond, args, err := filter.SQL(node, userID, today)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	err = config.DB.Model(&models.Transaction{}).Where("transactions.user_id = ?", userID).Where(cond, args...).
+		Order("transactions.id").Limit(maxBulkItems+1).Pluck("transactions.id", &ids).Error
+	return ids, err
+}
+
+// patch builds the input of an update, recategorize or tag from the stored transaction
+func (op BulkOperation) patch(db *gorm.DB, tx models.Transaction) (TransactionInput, error) {
+	in := transactionInput(tx)
+	switch op.Op {
+	case "recategorize":
+		in.CategoryID = op.CategoryID
+	case "tag":
+		current := []models.Transaction{tx}
+		if err := loadTags(db, current); err != nil {
+			return in, err
+		}
+		add, err := normalizeTags(op.Add)
+		if err != nil {
+			return in, invalidItem(err)
+		}
+		remove, err := normalizeTags(op.Remove)
+		if err != nil {
+			return in, invalidItem(err)
+		}
+		drop := make(map[string]bool)
+		for _, t := range remove {
+			drop[t] = true
+		}
+		in.Tags = []string{}
+		for _, t := range append(current[0].Tags, add...) {
+			if !drop[t] {
+				in.Tags = append(in.Tags, t)
+			}
+		}
+	case "update":
+		p := op.Set
+		if p.Amount != nil {
+			in.Amount = *p.Amount
+		}
+		if p.Date != nil {
+			in.Date = *p.Date
+		}
+		if p.CategoryID != nil {
+			in.CategoryID = *p.CategoryID
+		}
+		if p.AccountID.Set {
+			in.AccountID = p.AccountID.Value
+		}
+		if p.Payee != nil {
+			in.Payee = *p.Payee
+		}
+		if p.Description != nil {
+			in.Description = *p.Description
+		}
+		if p.Notes != nil {
+			in.Notes = *p.Notes
+		}
+		if p.Tags != nil {
+			in.Tags = p.Tags
+		} else {
+			current := []models.Transaction{tx}
+			if err := loadTags(db, current); err != nil {
+				return in, err
+			}
+			in.Tags = current[0].Tags
+		}
+	}
+	return in, nil
+}
+
+// apply runs an operation on one transaction of the actor; id is 0 for create
+func (op BulkOperation) apply(db *gorm.DB, actor audit.Actor, id uint) (models.Transaction, int, error) {
+	if op.Op == "create" {
+		tx := models.Transaction{UserID: actor.UserID}
+		if err := applyInput(&tx, *op.Transaction); err != nil {
+			return tx, 0, invalidItem(err)
+		}
+		return tx, http.StatusCreated, saveTransaction(db, actor, audit.ActionCreate, &tx, nil)
+	}
+	var tx models.Transaction
+	if err := db.Where("id = ? AND user_id = ?", id, actor.UserID).First(&tx).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx, 0, &bulkError{http.StatusNotFound, errors.New("Transaction not found")}
+		}
+		return tx, 0, err
+	}
+	if op.Op == "delete" {
+		return tx, http.StatusNoContent, deleteTransaction(db, actor, tx)
+	}
+	in, err := op.patch(db, tx)
+	if err != nil {
+		return tx, 0, err
+	}
+	before := tx
+	if err := applyInput(&tx, in); err != nil {
+		return tx, 0, invalidItem(err)
+	}
+	return tx, http.StatusOK, saveTransaction(db, actor, audit.ActionUpdate, &tx, &before)
+}
+
+// BulkTransactions creates, updates, deletes, recategorizes or tags many transactions at once
+// @Summary Bulk transaction operations
+// @Description Runs a list of operations in a single database transaction and reports the outcome for every transaction touched. update, delete, recategorize and tag select transactions by ids or by a filter expression. Without atomic, failed items are skipped and the rest is committed; with atomic, any failure rolls everything back and the response is 400.
+// @Tags transactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body BulkRequest true "Operations"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} BulkResponse
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /transactions/bulk [post]
+func BulkTransactions(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input BulkRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targets := make([][]uint, len(input.Operations))
+	total := 0
+	for i, op := range input.Operations {
+		if err := op.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("operation %d: %s", i, err)})
+			return
+		}
+		if targets[i], err = op.targets(userID, today(loc)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("operation %d: %s", i, err)})
+			return
+		}
+		if total += len(targets[i]); total > maxBulkItems {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A bulk request may touch at most %d transactions", maxBulkItems)})
+			return
+		}
+	}
+
+	resp := BulkResponse{Results: []BulkResult{}}
+	var touched []models.Transaction
+	err = config.DB.Transaction(func(db *gorm.DB) error {
+		for i, op := range input.Operations {
+			for _, id := range targets[i] {
+				result := BulkResult{Index: i, Op: op.Op, ID: id}
+				var tx models.Transaction
+				// each item runs in a savepoint so that a failure only undoes that item
+				err := db.Transaction(func(db *gorm.DB) error {
+					var err error
+					tx, result.Status, err = op.apply(db, actorOf(c), id)
+					return err
+				})
+				var itemErr *bulkError
+				switch {
+				case errors.As(err, &itemErr):
+					result.Status, result.Error = itemErr.status, itemErr.Error()
+				case errors.Is(err, errVersionConflict):
+					result.Status, result.Error = http.StatusPreconditionFailed, err.Error()
+				case err != nil:
+					result.Status, result.Error = http.StatusInternalServerError, err.Error()
+				default:
+					result.ID = tx.ID
+					if op.Op != "delete" {
+						touched = append(touched, tx)
+					}
+				}
+				resp.Results = append(resp.Results, result)
+				if result.Error != "" {
+					resp.Failed++
+					if input.Atomic {
+						return errRollback
+					}
+				} else {
+					resp.Succeeded++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		resp.Succeeded = 0
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	resp.Committed = true
+	seen := make(map[string]bool)
+	for _, tx := range touched {
+		key := fmt.Sprintf("%d/%s", tx.CategoryID, tx.Date.Format("2006-01-02"))
+		if !seen[key] {
+			seen[key] = true
+			checkBudgetAlerts(userID, tx.CategoryID, tx.Date)
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkOperationValidate(t *testing.T) {
+	assert.NoError(t, BulkOperation{Op: "delete", IDs: []uint{1}}.validate())
+	assert.NoError(t, BulkOperation{Op: "recategorize", Filter: "payee ~ amazon", CategoryID: 3}.validate())
+	for _, op := range []BulkOperation{
+		{Op: "create"},
+		{Op: "delete"},
+		{Op: "delete", IDs: []uint{1}, Filter: "amount < 0"},
+		{Op: "update", IDs: []uint{1}},
+		{Op: "recategorize", IDs: []uint{1}},
+		{Op: "tag", IDs: []uint{1}},
+	} {
+		assert.Error(t, op.validate(), op.Op)
+	}
+}
+
+func TestBulkOperationPatch(t *testing.T) {
+	setupTestDB(t)
+	account := uint(2)
+	tx := models.Transaction{ID: 1, UserID: 1, Amount: -20, Date: time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC), CategoryID: 5, Payee: "Shop", Notes: "n"}
+	payee, amount := "Corner Market", -25.0
+	in, err := BulkOperation{Op: "update", Set: &TransactionPatch{Payee: &payee, Amount: &amount, AccountID: nullableUint{true, &account}}}.patch(config.DB, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, TransactionInput{Amount: -25, Date: "2025-08-03", CategoryID: 5, AccountID: &account, Payee: "Corner Market", Notes: "n", Tags: []string{}}, in)
+
+	in, err = BulkOperation{Op: "recategorize", CategoryID: 9}.patch(config.DB, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), in.CategoryID)
+	assert.Nil(t, in.Tags)
+}
+
+func TestTransactionPatchAccountID(t *testing.T) {
+	var p TransactionPatch
+	assert.NoError(t, json.Unmarshal([]byte(`{"payee":"x"}`), &p))
+	assert.False(t, p.AccountID.Set)
+	assert.NoError(t, json.Unmarshal([]byte(`{"account_id":null}`), &p))
+	assert.True(t, p.AccountID.Set)
+	assert.Nil(t, p.AccountID.Value)
+	p = TransactionPatch{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"account_id":3}`), &p))
+	assert.Equal(t, uint(3), *p.AccountID.Value)
+}
+
+func TestBulkUpdateKeepsOmittedFields(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "bulk@example.com")
+	food := models.Category{UserID: user.ID, Name: "Food", Kind: models.CategoryKindExpense, Version: 1}
+	config.DB.Create(&food)
+	card := models.Account{UserID: user.ID, Name: "Card", Type: "credit"}
+	config.DB.Create(&card)
+	tx := models.Transaction{UserID: user.ID, CategoryID: food.ID, AccountID: &card.ID, Amount: -20, Date: time.Now(), Version: 1}
+	config.DB.Create(&tx)
+	assert.NoError(t, setTransactionTags(config.DB, user.ID, tx.ID, []string{"lunch", "work"}))
+	id := strconv.Itoa(int(tx.ID))
+	bulk := func(set string) {
+		t.Helper()
+		w := serve(user.ID, BulkTransactions, http.MethodPost, "/transactions/bulk", "/transactions/bulk",
+			`{"operations":[{"op":"update","ids":[`+id+`],"set":`+set+`}]}`)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	stored := func() models.Transaction {
+		var current models.Transaction
+		config.DB.First(&current, tx.ID)
+		txs := []models.Transaction{current}
+		loadTags(config.DB, txs)
+		return txs[0]
+	}
+
+	bulk(`{"payee":"Canteen"}`)
+	got := stored()
+	assert.Equal(t, "Canteen", got.Payee)
+	assert.Equal(t, &card.ID, got.AccountID, "an omitted account_id is kept")
+	assert.Equal(t, []string{"lunch", "work"}, got.Tags, "omitted tags are kept")
+
+	bulk(`{"account_id":null,"tags":["work"]}`)
+	got = stored()
+	assert.Nil(t, got.AccountID, "null clears the account")
+	assert.Equal(t, []string{"work"}, got.Tags)
+	assert.Equal(t, "Canteen", got.Payee)
+}
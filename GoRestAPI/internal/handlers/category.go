@@ -1,30 +1,67 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"expense-tracker/internal/models"
 	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/search"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CategoryInput struct {
-	Name string `json:"name" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	ParentID  *uint  `json:"parent_id"`
+	Kind      string `json:"kind" binding:"omitempty,oneof=income expense transfer"`
+	Color     string `json:"color" binding:"omitempty,hexcolor"`
+	Icon      string `json:"icon"`
+	Archived  bool   `json:"archived"`
+	SortOrder int    `json:"sort_order"`
 }
 
-// ListCategories returns all categories for the authenticated user
+// apply copies the input onto a category. An omitted kind keeps the category's
+// current kind, or makes it an expense category if it has none.
+func (in CategoryInput) apply(cat *models.Category) {
+	cat.Name = in.Name
+	cat.ParentID = in.ParentID
+	if in.Kind != "" {
+		cat.Kind = in.Kind
+	}
+	if cat.Kind == "" {
+		cat.Kind = models.CategoryKindExpense
+	}
+	cat.Color = in.Color
+	cat.Icon = in.Icon
+	cat.Archived = in.Archived
+	cat.SortOrder = in.SortOrder
+}
+
+// ListCategories returns the categories for the authenticated user
 // @Summary List categories
-// @Description Get all categories for the current user
+// @Description Get the categories for the current user ordered by sort order. Archived categories are hidden unless include_archived is true.
 // @Tags categories
 // @Security BearerAuth
 // @Produce json
+// @Param include_archived query bool false "Include archived categories"
+// @Param kind query string false "Filter by kind (income, expense, transfer)"
 // @Success 200 {array} models.Category
 // @Failure 401 {object} gin.H{"error":string}
 // @Router /categories [get]
 func ListCategories(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	var cats []models.Category
-	if err := config.DB.Where("user_id = ?", userID).Find(&cats).Error; err != nil {
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("include_archived") != "true" {
+		query = query.Where("archived = ?", false)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if err := query.Order("sort_order, name").Find(&cats).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -33,7 +70,7 @@ func ListCategories(c *gin.Context) {
 
 // CreateCategory creates a new category for the authenticated user
 // @Summary Create category
-// @Description Create a new category for the current user
+// @Description Create a new category for the current user. A subcategory created without a kind takes the kind of its parent; other categories default to expense.
 // @Tags categories
 // @Security BearerAuth
 // @Accept json
@@ -50,14 +87,47 @@ func CreateCategory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	cat := models.Category{Name: input.Name, UserID: userID}
-	if err := config.DB.Create(&cat).Error; err != nil {
+	if err := validateParent(userID, 0, input.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cat := models.Category{UserID: userID, Kind: parentKind(userID, input.ParentID), Version: 1}
+	input.apply(&cat)
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		if err := db.Create(&cat).Error; err != nil {
+			return err
+		}
+		return audit.Record(db, actorOf(c), audit.ActionCreate, audit.EntityCategory, cat.ID, cat.Version, nil, cat)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", etag(cat.Version))
 	c.JSON(http.StatusCreated, cat)
 }
 
+// GetCategory returns a category by ID for the authenticated user
+// @Summary Get category
+// @Description Get a category by ID for the current user
+// @Tags categories
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} models.Category
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /categories/{id} [get]
+func GetCategory(c *gin.Context) {
+	var cat models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&cat).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	c.Header("ETag", etag(cat.Version))
+	c.JSON(http.StatusOK, cat)
+}
+
 // UpdateCategory updates a category for the authenticated user
 // @Summary Update category
 // @Description Update a category for the current user
@@ -66,11 +136,13 @@ func CreateCategory(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag of the version being replaced"
 // @Param input body CategoryInput true "Category info"
 // @Success 200 {object} models.Category
 // @Failure 400 {object} gin.H{"error":string}
 // @Failure 401 {object} gin.H{"error":string}
 // @Failure 404 {object} gin.H{"error":string}
+// @Failure 412 {object} gin.H{"error":string}
 // @Router /categories/{id} [put]
 func UpdateCategory(c *gin.Context) {
 	userID := c.GetUint("user_id")
@@ -80,19 +152,104 @@ func UpdateCategory(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
+	if !ifMatch(c, cat.Version) {
+		return
+	}
 	var input CategoryInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	cat.Name = input.Name
-	config.DB.Save(&cat)
+	updateCategory(c, cat, input)
+}
+
+// PatchCategory partially updates a category for the authenticated user
+// @Summary Patch category
+// @Description Update some fields of a category with a JSON Merge Patch (RFC 7386): members present in the body replace the stored values, null clears parent_id, color or icon, and omitted members are kept.
+// @Tags categories
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Param input body object true "Merge patch"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 412 {object} gin.H{"error":string}
+// @Router /categories/{id} [patch]
+func PatchCategory(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	var cat models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&cat).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	if !ifMatch(c, cat.Version) {
+		return
+	}
+	input := CategoryInput{
+		Name:      cat.Name,
+		ParentID:  cat.ParentID,
+		Kind:      cat.Kind,
+		Color:     cat.Color,
+		Icon:      cat.Icon,
+		Archived:  cat.Archived,
+		SortOrder: cat.SortOrder,
+	}
+	if err := bindMergePatch(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updateCategory(c, cat, input)
+}
+
+// updateCategory applies input to a stored category and responds with the result
+func updateCategory(c *gin.Context, cat models.Category, input CategoryInput) {
+	if err := validateParent(cat.UserID, cat.ID, input.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	renamed := input.Name != cat.Name
+	before := cat
+	input.apply(&cat)
+	if cat.Kind != before.Kind && countKindConflicts(cat.ID, cat.Kind) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category has transactions whose sign does not match the new kind"})
+		return
+	}
+	cat.Version = before.Version + 1
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		result := db.Model(&cat).Where("version = ?", before.Version).Select("*").Updates(&cat)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		return audit.Record(db, actorOf(c), audit.ActionUpdate, audit.EntityCategory, cat.ID, cat.Version, before, cat)
+	})
+	if errors.Is(err, errVersionConflict) {
+		var current models.Category
+		config.DB.Select("version").First(&current, cat.ID)
+		preconditionFailed(c, current.Version)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if renamed {
+		reindexCategory(cat.ID)
+	}
+	c.Header("ETag", etag(cat.Version))
 	c.JSON(http.StatusOK, cat)
 }
 
-// DeleteCategory deletes a category for the authenticated user
+// DeleteCategory moves a category of the authenticated user to the trash
 // @Summary Delete category
-// @Description Delete a category for the current user
+// @Description Move a category of the current user to the trash. Its subcategories become top-level categories; its transactions are kept and still count under it everywhere until they are moved to another category.
 // @Tags categories
 // @Security BearerAuth
 // @Param id path int true "Category ID"
@@ -103,9 +260,110 @@ func UpdateCategory(c *gin.Context) {
 func DeleteCategory(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Category{}).Error; err != nil {
+	var cat models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&cat).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	actor := actorOf(c)
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		if err := db.Delete(&cat).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(db, actor, audit.ActionDelete, audit.EntityCategory, cat.ID, cat.Version, cat, nil); err != nil {
+			return err
+		}
+		// subcategories become top-level categories
+		var children []models.Category
+		if err := db.Where("parent_id = ? AND user_id = ?", cat.ID, userID).Find(&children).Error; err != nil {
+			return err
+		}
+		for _, child := range children {
+			before := child
+			child.ParentID = nil
+			child.Version++
+			if err := db.Model(&child).Updates(map[string]interface{}{"parent_id": nil, "version": child.Version}).Error; err != nil {
+				return err
+			}
+			if err := audit.Record(db, actor, audit.ActionUpdate, audit.EntityCategory, child.ID, child.Version, before, child); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// reindexCategory refreshes the search index entries of a category's transactions after its name changed
+func reindexCategory(categoryID uint) {
+	var ids []uint
+	config.DB.Model(&models.Transaction{}).Where("category_id = ?", categoryID).Pluck("id", &ids)
+	if err := search.Index(config.DB, ids...); err != nil {
+		log.Printf("failed to reindex transactions of category %d: %v", categoryID, err)
+	}
+}
+
+// parentKind returns the kind of a category's parent, which subcategories
+// created without a kind inherit, or "" for a top-level category
+func parentKind(userID uint, parentID *uint) string {
+	if parentID == nil {
+		return ""
+	}
+	var parent models.Category
+	config.DB.Select("kind").Where("id = ? AND user_id = ?", *parentID, userID).First(&parent)
+	return parent.Kind
+}
+
+// countKindConflicts returns how many transactions of a category would violate the sign rule of kind
+func countKindConflicts(categoryID uint, kind string) int64 {
+	var n int64
+	query := config.DB.Model(&models.Transaction{}).Where("category_id = ?", categoryID)
+	switch kind {
+	case models.CategoryKindIncome:
+		query = query.Where("amount <= 0")
+	case models.CategoryKindExpense:
+		query = query.Where("amount >= 0")
+	default:
+		return 0
+	}
+	query.Count(&n)
+	return n
+}
+
+// validateParent checks that a category group parent belongs to the user and is
+// itself a top-level category. Groups are one level deep.
+func validateParent(userID, categoryID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == categoryID {
+		return errors.New("Category cannot be its own parent")
+	}
+	var parent models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", *parentID, userID).First(&parent).Error; err != nil {
+		return errors.New("Parent category not found")
+	}
+	if parent.ParentID != nil {
+		return errors.New("Parent category must be a top-level category")
+	}
+	if categoryID != 0 {
+		var children int64
+		config.DB.Unscoped().Model(&models.Category{}).Where("parent_id = ?", categoryID).Count(&children)
+		if children > 0 {
+			return errors.New("Category with subcategories cannot have a parent")
+		}
+	}
+	return nil
+}
+
+// categoryGroupIDs returns the ID of a category and of its subcategories
+func categoryGroupIDs(userID, categoryID uint) []uint {
+	ids := []uint{categoryID}
+	var children []uint
+	config.DB.Unscoped().Model(&models.Category{}).Where("user_id = ? AND parent_id = ?", userID, categoryID).Pluck("id", &children)
+	return append(ids, children...)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryInputApply(t *testing.T) {
+	cat := models.Category{ID: 4, Name: "Salary", Kind: models.CategoryKindIncome, Color: "#22c55e"}
+	CategoryInput{Name: "Wages"}.apply(&cat)
+	assert.Equal(t, "Wages", cat.Name)
+	assert.Equal(t, models.CategoryKindIncome, cat.Kind, "an omitted kind is kept")
+	assert.Empty(t, cat.Color)
+
+	CategoryInput{Name: "Wages", Kind: models.CategoryKindTransfer}.apply(&cat)
+	assert.Equal(t, models.CategoryKindTransfer, cat.Kind)
+
+	cat = models.Category{}
+	CategoryInput{Name: "Misc"}.apply(&cat)
+	assert.Equal(t, models.CategoryKindExpense, cat.Kind)
+}
+
+func createTestCategory(t *testing.T, userID uint, body string) models.Category {
+	t.Helper()
+	w := serve(userID, CreateCategory, http.MethodPost, "/categories", "/categories", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create category: %d %s", w.Code, w.Body)
+	}
+	var cat models.Category
+	json.Unmarshal(w.Body.Bytes(), &cat)
+	return cat
+}
+
+func TestCategoryKinds(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "kinds@example.com")
+
+	salary := createTestCategory(t, user.ID, `{"name":"Salary","kind":"income"}`)
+	bonus := createTestCategory(t, user.ID, `{"name":"Bonus","parent_id":`+strconv.Itoa(int(salary.ID))+`}`)
+	assert.Equal(t, models.CategoryKindIncome, bonus.Kind, "subcategories inherit the parent's kind")
+	misc := createTestCategory(t, user.ID, `{"name":"Misc"}`)
+	assert.Equal(t, models.CategoryKindExpense, misc.Kind)
+
+	config.DB.Create(&models.Transaction{UserID: user.ID, CategoryID: salary.ID, Amount: 3000, Date: time.Now(), Version: 1})
+	path := "/categories/" + strconv.Itoa(int(salary.ID))
+	update := func(method, body string) (int, models.Category) {
+		handler := UpdateCategory
+		if method == http.MethodPatch {
+			handler = PatchCategory
+		}
+		w := serve(user.ID, handler, method, "/categories/:id", path, body)
+		var cat models.Category
+		json.Unmarshal(w.Body.Bytes(), &cat)
+		return w.Code, cat
+	}
+
+	code, cat := update(http.MethodPut, `{"name":"Wages"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.CategoryKindIncome, cat.Kind, "PUT without kind keeps it")
+	code, cat = update(http.MethodPatch, `{"kind":null}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.CategoryKindIncome, cat.Kind, "PATCH with a null kind keeps it")
+
+	code, _ = update(http.MethodPut, `{"name":"Wages","kind":"expense"}`)
+	assert.Equal(t, http.StatusBadRequest, code, "the income transaction does not fit an expense category")
+	code, cat = update(http.MethodPatch, `{"kind":"transfer"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.CategoryKindTransfer, cat.Kind)
+}
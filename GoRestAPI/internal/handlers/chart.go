@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/chart"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// chartMaxDays limits the range of the balance chart, which has a point per day
+const chartMaxDays = 366
+
+// chartSize reads the width and height query parameters
+func chartSize(c *gin.Context) (int, int, error) {
+	width, height := 640, 360
+	if v := c.Query("width"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 200 || n > 2000 {
+			return 0, 0, errors.New("width must be between 200 and 2000")
+		}
+		width = n
+	}
+	if v := c.Query("height"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 150 || n > 1500 {
+			return 0, 0, errors.New("height must be between 150 and 1500")
+		}
+		height = n
+	}
+	return width, height, nil
+}
+
+// renderChart writes the chart in the requested format, svg (default) or png
+func renderChart(c *gin.Context, ch chart.Chart) {
+	width, height, err := chartSize(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var buf bytes.Buffer
+	contentType := "image/svg+xml"
+	switch c.DefaultQuery("format", "svg") {
+	case "svg":
+		err = chart.SVG(&buf, ch, width, height)
+	case "png":
+		contentType = "image/png"
+		err = chart.PNG(&buf, ch, width, height)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be svg or png"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=60")
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func periodLabel(from, to time.Time) string {
+	return from.Format("Jan 2") + " - " + to.AddDate(0, 0, -1).Format("Jan 2, 2006")
+}
+
+// GetCategoryChart draws the category breakdown of the authenticated user as a pie chart
+// @Summary Category breakdown chart
+// @Description Pie chart of expenses (or income) by category over the date range; transfers are excluded and small categories are grouped into "Other"
+// @Tags charts
+// @Security BearerAuth
+// @Produce image/svg+xml
+// @Produce image/png
+// @Param start_date query string false "Start date (YYYY-MM-DD, default: first day of the current month)"
+// @Param end_date query string false "End date (YYYY-MM-DD, default: last day of the current month)"
+// @Param type query string false "expense (default) or income"
+// @Param account_id query int false "Account ID"
+// @Param format query string false "svg (default) or png"
+// @Param width query int false "Width in pixels (default: 640)"
+// @Param height query int false "Height in pixels (default: 360)"
+// @Success 200 {file} file
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /charts/categories [get]
+func GetCategoryChart(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kind := c.DefaultQuery("type", "expense")
+	if kind != "expense" && kind != "income" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be expense or income"})
+		return
+	}
+	totals, err := categoryTotals(c.GetUint("user_id"), from, to, c.Query("account_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	pie := chart.Pie{Title: "Expenses by category, " + periodLabel(from, to)}
+	if kind == "income" {
+		pie.Title = "Income by category, " + periodLabel(from, to)
+	}
+	for _, t := range totals {
+		// refunds can leave an expense category with a positive total
+		switch {
+		case t.Kind == models.CategoryKindTransfer:
+		case kind == "income" && t.Kind == models.CategoryKindIncome && t.Amount > 0:
+			pie.Slices = append(pie.Slices, chart.Slice{Label: t.Name, Value: t.Amount})
+		case kind == "expense" && t.Kind != models.CategoryKindIncome && t.Amount < 0:
+			pie.Slices = append(pie.Slices, chart.Slice{Label: t.Name, Value: t.Amount})
+		}
+	}
+	renderChart(c, pie)
+}
+
+// GetIncomeExpenseChart draws monthly income and expenses of the authenticated user as a bar chart
+// @Summary Income vs expense chart
+// @Description Bar chart of income and expenses for each of the last months up to the current month, transfers excluded
+// @Tags charts
+// @Security BearerAuth
+// @Produce image/svg+xml
+// @Produce image/png
+// @Param months query int false "Number of months, 1 to 36 (default: 12)"
+// @Param account_id query int false "Account ID"
+// @Param tz query string false "IANA timezone (default: the user's timezone)"
+// @Param format query string false "svg (default) or png"
+// @Param width query int false "Width in pixels (default: 640)"
+// @Param height query int false "Height in pixels (default: 360)"
+// @Success 200 {file} file
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /charts/income-expense [get]
+func GetIncomeExpenseChart(c *gin.Context) {
+	months := 12
+	if v := c.Query("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 36 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "months must be between 1 and 36"})
+			return
+		}
+		months = n
+	}
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := today(loc)
+	start := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	bar := chart.Bar{
+		Title:  "Income and expenses",
+		Series: []chart.Series{{Name: "Income"}, {Name: "Expenses"}},
+	}
+	for i := 0; i < months; i++ {
+		from := start.AddDate(0, i, 0)
+		to := from.AddDate(0, 1, 0)
+		totals, err := categoryTotals(c.GetUint("user_id"), from, to, c.Query("account_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		p := periodTotals(from, to, totals)
+		bar.Labels = append(bar.Labels, from.Format("Jan '06"))
+		bar.Series[0].Values = append(bar.Series[0].Values, p.Income)
+		bar.Series[1].Values = append(bar.Series[1].Values, -p.Expense)
+	}
+	renderChart(c, bar)
+}
+
+// dailyBalances returns the balance at the end of each of the days from from,
+// given the opening balance and the transactions selected by scope
+func dailyBalances(scope func() *gorm.DB, opening float64, from time.Time, days int) ([]float64, error) {
+	var before float64
+	if err := scope().Where("date < ?", from).Select("COALESCE(SUM(amount), 0)").Scan(&before).Error; err != nil {
+		return nil, err
+	}
+	rows, err := scope().Where("date >= ? AND date < ?", from, from.AddDate(0, 0, days)).Select("date, amount").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := make([]float64, days)
+	for rows.Next() {
+		var date time.Time
+		var amount float64
+		if err := rows.Scan(&date, &amount); err != nil {
+			return nil, err
+		}
+		if day := int(date.UTC().Sub(from).Hours() / 24); day >= 0 && day < days {
+			changes[day] += amount
+		}
+	}
+	balances := make([]float64, days)
+	balance := opening + before
+	for i, change := range changes {
+		balance += change
+		balances[i] = balance
+	}
+	return balances, rows.Err()
+}
+
+// GetBalanceChart draws the daily balance of the authenticated user's accounts as a line chart
+// @Summary Balance chart
+// @Description Line chart of the end-of-day balance of each account (plus a total when there are several, and "No account" for unassigned transactions) over the date range, at most 366 days
+// @Tags charts
+// @Security BearerAuth
+// @Produce image/svg+xml
+// @Produce image/png
+// @Param start_date query string false "Start date (YYYY-MM-DD, default: 89 days before end_date)"
+// @Param end_date query string false "End date (YYYY-MM-DD, default: today)"
+// @Param account_id query int false "Only this account"
+// @Param tz query string false "IANA timezone (default: the user's timezone)"
+// @Param format query string false "svg (default) or png"
+// @Param width query int false "Width in pixels (default: 640)"
+// @Param height query int false "Height in pixels (default: 360)"
+// @Success 200 {file} file
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /charts/balances [get]
+func GetBalanceChart(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to := today(loc).AddDate(0, 0, 1)
+	if end := c.Query("end_date"); end != "" {
+		v, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD."})
+			return
+		}
+		to = v.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -90)
+	if start := c.Query("start_date"); start != "" {
+		if from, err = time.Parse("2006-01-02", start); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD."})
+			return
+		}
+	}
+	days := int(to.Sub(from).Hours() / 24)
+	if days < 1 || days > chartMaxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The date range must cover 1 to 366 days"})
+		return
+	}
+
+	var accounts []models.Account
+	query := config.DB.Where("user_id = ?", userID)
+	if id := c.Query("account_id"); id != "" {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("archived = ?", false)
+	}
+	if err := query.Order("name").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("account_id") != "" && len(accounts) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	line := chart.Line{Title: "Balances, " + periodLabel(from, to)}
+	for _, acc := range accounts {
+		id := acc.ID
+		values, err := dailyBalances(func() *gorm.DB {
+			return config.DB.Model(&models.Transaction{}).Where("account_id = ?", id)
+		}, acc.OpeningBalance, from, days)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		line.Series = append(line.Series, chart.Series{Name: acc.Name, Values: values})
+	}
+	if c.Query("account_id") == "" {
+		var unassigned int64
+		config.DB.Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NULL AND date < ?", userID, to).Count(&unassigned)
+		if unassigned > 0 {
+			values, err := dailyBalances(func() *gorm.DB {
+				return config.DB.Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NULL", userID)
+			}, 0, from, days)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			line.Series = append(line.Series, chart.Series{Name: "No account", Values: values})
+		}
+		if len(line.Series) > 1 {
+			total := chart.Series{Name: "Total", Values: make([]float64, days)}
+			for _, s := range line.Series {
+				for i, v := range s.Values {
+					total.Values[i] += v
+				}
+			}
+			line.Series = append(line.Series, total)
+		}
+	}
+	for i := 0; i < days; i++ {
+		line.Labels = append(line.Labels, from.AddDate(0, 0, i).Format("Jan 2"))
+	}
+	renderChart(c, line)
+}
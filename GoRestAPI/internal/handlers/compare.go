@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/rollup"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PeriodTotals struct {
+	StartDate string  `json:"start_date"`
+	EndDate   string  `json:"end_date"`
+	Income    float64 `json:"income"`
+	Expense   float64 `json:"expense"`
+	Net       float64 `json:"net"`
+}
+
+type CategoryComparison struct {
+	CategoryID   uint     `json:"category_id"`
+	CategoryName string   `json:"category_name"`
+	Current      float64  `json:"current"`
+	Previous     float64  `json:"previous"`
+	Delta        float64  `json:"delta"`
+	PercentDelta *float64 `json:"percent_delta"`
+	// Status is "new" when the category only has transactions in the current
+	// period, "missing" when it only has them in the previous one, else "both"
+	Status string `json:"status"`
+}
+
+type ComparisonResponse struct {
+	Current    PeriodTotals         `json:"current"`
+	Previous   PeriodTotals         `json:"previous"`
+	Categories []CategoryComparison `json:"categories"`
+}
+
+type categoryTotal struct {
+	Name   string
+	Kind   string
+	Amount float64
+}
+
+// previousPeriod returns the period of the same length immediately before [from, to).
+// Month-aligned ranges are shifted by whole months so that, for example, the
+// period before February is January rather than the last 28 days of January.
+func previousPeriod(from, to time.Time) (time.Time, time.Time) {
+	if from.Day() == 1 && to.Day() == 1 {
+		months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+		return from.AddDate(0, -months, 0), from
+	}
+	return from.Add(-to.Sub(from)), from
+}
+
+// categoryTotals sums the user's transactions per category over [from, to).
+// Whole-month ranges without an account filter are read from the monthly rollups.
+func categoryTotals(userID uint, from, to time.Time, accountID string) (map[uint]categoryTotal, error) {
+	var query *gorm.DB
+	if accountID == "" && rollup.Aligned(from, to) {
+		query = config.DB.Table("monthly_rollups r").
+			Select("c.id, c.name, COALESCE(c.kind, ''), SUM(r.income + r.expense)").
+			Joins("JOIN categories c ON r.category_id = c.id").
+			Where("r.user_id = ? AND r.month >= ? AND r.month < ?", userID, from, to)
+	} else {
+		query = config.DB.Table("transactions t").
+			Select("c.id, c.name, COALESCE(c.kind, ''), SUM(t.amount)").
+			Joins("JOIN categories c ON t.category_id = c.id").
+			Where("t.user_id = ? AND t.deleted_at IS NULL AND t.date >= ? AND t.date < ?", userID, from, to)
+		if accountID != "" {
+			query = query.Where("t.account_id = ?", accountID)
+		}
+	}
+	query = query.Group("c.id, c.name, c.kind")
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	totals := make(map[uint]categoryTotal)
+	for rows.Next() {
+		var id uint
+		var total categoryTotal
+		if err := rows.Scan(&id, &total.Name, &total.Kind, &total.Amount); err != nil {
+			return nil, err
+		}
+		totals[id] = total
+	}
+	return totals, rows.Err()
+}
+
+// periodTotals summarises category totals into income, expense and net, ignoring transfers
+func periodTotals(from, to time.Time, totals map[uint]categoryTotal) PeriodTotals {
+	p := PeriodTotals{StartDate: from.Format("2006-01-02"), EndDate: to.AddDate(0, 0, -1).Format("2006-01-02")}
+	for _, t := range totals {
+		switch t.Kind {
+		case models.CategoryKindTransfer:
+		case models.CategoryKindIncome:
+			p.Income += t.Amount
+		default:
+			p.Expense += t.Amount
+		}
+	}
+	p.Net = p.Income + p.Expense
+	return p
+}
+
+// compareCategories pairs the category totals of two periods, largest changes first
+func compareCategories(current, previous map[uint]categoryTotal) []CategoryComparison {
+	list := []CategoryComparison{}
+	for id, cur := range current {
+		item := CategoryComparison{CategoryID: id, CategoryName: cur.Name, Current: cur.Amount, Status: "new"}
+		if prev, ok := previous[id]; ok {
+			item.Previous = prev.Amount
+			item.Status = "both"
+		}
+		list = append(list, item)
+	}
+	for id, prev := range previous {
+		if _, ok := current[id]; !ok {
+			list = append(list, CategoryComparison{CategoryID: id, CategoryName: prev.Name, Previous: prev.Amount, Status: "missing"})
+		}
+	}
+	for i := range list {
+		list[i].Delta = list[i].Current - list[i].Previous
+		if list[i].Previous != 0 {
+			pct := list[i].Delta / math.Abs(list[i].Previous) * 100
+			list[i].PercentDelta = &pct
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if math.Abs(list[i].Delta) != math.Abs(list[j].Delta) {
+			return math.Abs(list[i].Delta) > math.Abs(list[j].Delta)
+		}
+		return list[i].CategoryName < list[j].CategoryName
+	})
+	return list
+}
+
+// GetComparisonReport compares per-category totals between two periods
+// @Summary Compare two periods
+// @Description Returns per-category totals for a period (default: current month) and a comparison period, with absolute and percent deltas. The comparison period is given with compare_start_date/compare_end_date or a preset: previous_period (default) or same_period_last_year. Categories only present in one period are marked new or missing.
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param compare_start_date query string false "Comparison start date (YYYY-MM-DD)"
+// @Param compare_end_date query string false "Comparison end date (YYYY-MM-DD)"
+// @Param preset query string false "previous_period or same_period_last_year"
+// @Param account_id query int false "Account ID"
+// @Success 200 {object} ComparisonResponse
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/compare [get]
+func GetComparisonReport(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var prevFrom, prevTo time.Time
+	if c.Query("compare_start_date") != "" || c.Query("compare_end_date") != "" {
+		prevFrom, err = time.Parse("2006-01-02", c.Query("compare_start_date"))
+		if err == nil {
+			prevTo, err = time.Parse("2006-01-02", c.Query("compare_end_date"))
+		}
+		if err != nil || prevTo.Before(prevFrom) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "compare_start_date and compare_end_date must both be valid YYYY-MM-DD dates in order"})
+			return
+		}
+		prevTo = prevTo.AddDate(0, 0, 1)
+	} else {
+		switch c.DefaultQuery("preset", "previous_period") {
+		case "previous_period":
+			prevFrom, prevTo = previousPeriod(from, to)
+		case "same_period_last_year":
+			prevFrom, prevTo = from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "preset must be previous_period or same_period_last_year"})
+			return
+		}
+	}
+
+	account := c.Query("account_id")
+	current, err := categoryTotals(userID, from, to, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	previous, err := categoryTotals(userID, prevFrom, prevTo, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ComparisonResponse{
+		Current:    periodTotals(from, to, current),
+		Previous:   periodTotals(prevFrom, prevTo, previous),
+		Categories: compareCategories(current, previous),
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	d := func(s string) time.Time {
+		v, _ := time.Parse("2006-01-02", s)
+		return v
+	}
+	from, to := previousPeriod(d("2025-03-01"), d("2025-04-01"))
+	assert.Equal(t, d("2025-02-01"), from)
+	assert.Equal(t, d("2025-03-01"), to)
+
+	from, _ = previousPeriod(d("2025-07-01"), d("2025-10-01"))
+	assert.Equal(t, d("2025-04-01"), from)
+
+	from, to = previousPeriod(d("2025-07-10"), d("2025-07-20"))
+	assert.Equal(t, d("2025-06-30"), from)
+	assert.Equal(t, d("2025-07-10"), to)
+}
+
+func TestCompareCategories(t *testing.T) {
+	current := map[uint]categoryTotal{1: {Name: "Restaurants", Amount: -300}, 2: {Name: "Books", Amount: -20}}
+	previous := map[uint]categoryTotal{1: {Name: "Restaurants", Amount: -200}, 3: {Name: "Gym", Amount: -50}}
+	list := compareCategories(current, previous)
+	assert.Len(t, list, 3)
+	assert.Equal(t, "Restaurants", list[0].CategoryName)
+	assert.Equal(t, -100.0, list[0].Delta)
+	assert.Equal(t, -50.0, *list[0].PercentDelta)
+	assert.Equal(t, "missing", list[1].Status)
+	assert.Equal(t, "new", list[2].Status)
+	assert.Nil(t, list[2].PercentDelta)
+}
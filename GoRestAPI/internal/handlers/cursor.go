@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"expense-tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// transactionSortKeys maps the sort names accepted by ListTransactions to SQL
+// expressions; category sorts by name and needs the categories join as c
+var transactionSortKeys = map[string]string{
+	"date":        "transactions.date",
+	"amount":      "transactions.amount",
+	"category":    "COALESCE(c.name, '')",
+	"description": "COALESCE(transactions.description, '')",
+}
+
+// sortRelevance orders search results by relevance; it is the default when searching
+const sortRelevance = "relevance"
+
+// transactionSort is a parsed sort parameter such as "-amount"
+type transactionSort struct {
+	Key  string
+	Desc bool
+}
+
+// String returns the sort in its query parameter form
+func (s transactionSort) String() string {
+	if s.Desc {
+		return "-" + s.Key
+	}
+	return s.Key
+}
+
+// parseTransactionSort parses sort=[-]key; the default is newest first, or by
+// relevance when searching
+func parseTransactionSort(value string, searching bool) (transactionSort, error) {
+	if value == "" {
+		if searching {
+			return transactionSort{Key: sortRelevance}, nil
+		}
+		return transactionSort{Key: "date", Desc: true}, nil
+	}
+	s := transactionSort{Key: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+	if s.Key == sortRelevance && searching && !s.Desc {
+		return s, nil
+	}
+	if _, ok := transactionSortKeys[s.Key]; !ok {
+		return s, errors.New("sort must be one of date, amount, category or description, optionally prefixed with - for descending")
+	}
+	return s, nil
+}
+
+// listCursor is the position after the last row of a page. Keyset sorts
+// record the sort value and ID of that row; relevance records an offset since
+// scores change as transactions are added.
+type listCursor struct {
+	Sort   string          `json:"s"`
+	Value  json.RawMessage `json:"v,omitempty"`
+	ID     uint            `json:"id,omitempty"`
+	Offset int             `json:"o,omitempty"`
+}
+
+func encodeCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor and checks that it was issued for the same sort
+func decodeCursor(value string, sort transactionSort) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return c, errors.New("Invalid cursor")
+	}
+	if c.Sort != sort.String() {
+		return c, errors.New("Cursor was issued for a different sort")
+	}
+	return c, nil
+}
+
+// after restricts a keyset-sorted query to the rows following the cursor
+func (s transactionSort) after(query *gorm.DB, c listCursor) (*gorm.DB, error) {
+	var value interface{}
+	var err error
+	switch s.Key {
+	case "date":
+		var t time.Time
+		err = json.Unmarshal(c.Value, &t)
+		value = t
+	case "amount":
+		var f float64
+		err = json.Unmarshal(c.Value, &f)
+		value = f
+	default:
+		var str string
+		err = json.Unmarshal(c.Value, &str)
+		value = str
+	}
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	op := ">"
+	if s.Desc {
+		op = "<"
+	}
+	key := transactionSortKeys[s.Key]
+	return query.Where("("+key+" "+op+" ? OR ("+key+" = ? AND transactions.id "+op+" ?))", value, value, c.ID), nil
+}
+
+// order applies the sort, with the ID as tie-breaker so that keysets are unique
+func (s transactionSort) order(query *gorm.DB) *gorm.DB {
+	dir := " ASC"
+	if s.Desc {
+		dir = " DESC"
+	}
+	return query.Order(transactionSortKeys[s.Key] + dir).Order("transactions.id" + dir)
+}
+
+// cursorAfter returns the cursor following tx, whose category is named categoryName
+func (s transactionSort) cursorAfter(tx models.Transaction, categoryName string) string {
+	var value interface{}
+	switch s.Key {
+	case "date":
+		value = tx.Date
+	case "amount":
+		value = tx.Amount
+	case "category":
+		value = categoryName
+	default:
+		value = tx.Description
+	}
+	data, _ := json.Marshal(value)
+	return encodeCursor(listCursor{Sort: s.String(), Value: data, ID: tx.ID})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTransactionSort(t *testing.T) {
+	s, err := parseTransactionSort("", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "-date", s.String())
+	s, _ = parseTransactionSort("", true)
+	assert.Equal(t, sortRelevance, s.Key)
+	s, err = parseTransactionSort("-amount", false)
+	assert.NoError(t, err)
+	assert.Equal(t, transactionSort{Key: "amount", Desc: true}, s)
+
+	for _, v := range []string{"payee", "--date", "relevance", "id; DROP TABLE transactions"} {
+		_, err := parseTransactionSort(v, false)
+		assert.Error(t, err, v)
+	}
+	_, err = parseTransactionSort("-relevance", true)
+	assert.Error(t, err)
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort := transactionSort{Key: "date", Desc: true}
+	tx := models.Transaction{ID: 42, Date: time.Date(2025, 7, 19, 0, 0, 0, 0, time.UTC)}
+	cursor, err := decodeCursor(sort.cursorAfter(tx, ""), sort)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), cursor.ID)
+	assert.JSONEq(t, `"2025-07-19T00:00:00Z"`, string(cursor.Value))
+
+	// a cursor only continues the sort it was issued for
+	_, err = decodeCursor(sort.cursorAfter(tx, ""), transactionSort{Key: "date"})
+	assert.Error(t, err)
+	_, err = decodeCursor("not a cursor", sort)
+	assert.Error(t, err)
+}
+
+// listAll follows the cursors of ListTransactions from the first page to the
+// last and returns the IDs in the order they came back
+func listAll(t *testing.T, userID uint, query string) []uint {
+	t.Helper()
+	var ids []uint
+	path := "/transactions?" + query
+	for pages := 0; pages < 50; pages++ {
+		w := serve(userID, ListTransactions, http.MethodGet, "/transactions", path, "")
+		if !assert.Equal(t, http.StatusOK, w.Code, w.Body.String()) {
+			return ids
+		}
+		var page TransactionPage
+		json.Unmarshal(w.Body.Bytes(), &page)
+		for _, tx := range page.Data {
+			ids = append(ids, tx.ID)
+		}
+		if page.NextCursor == nil {
+			return ids
+		}
+		path = "/transactions?" + query + "&cursor=" + url.QueryEscape(*page.NextCursor)
+	}
+	t.Fatal("pagination did not end")
+	return nil
+}
+
+func TestListTransactionsByCategoryWithTrashedCategory(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "pages@example.com")
+	var want []uint
+	for _, name := range []string{"Books", "Food", "Rent"} {
+		cat := models.Category{UserID: user.ID, Name: name, Version: 1}
+		config.DB.Create(&cat)
+		for i := 0; i < 3; i++ {
+			tx := models.Transaction{UserID: user.ID, CategoryID: cat.ID, Amount: -1, Date: time.Now(), Version: 1}
+			config.DB.Create(&tx)
+			want = append(want, tx.ID)
+		}
+		if name == "Food" {
+			config.DB.Delete(&cat)
+		}
+	}
+
+	got := listAll(t, user.ID, "sort=category&limit=2")
+	assert.Len(t, got, len(want))
+	assert.ElementsMatch(t, want, got, "every transaction exactly once")
+}
+
+func TestListTransactionsPages(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "paging@example.com")
+	food := models.Category{UserID: user.ID, Name: "Food", Version: 1}
+	config.DB.Create(&food)
+	day := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	var ids []uint
+	add := func(amount float64, date time.Time, payee string) uint {
+		tx := models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: amount, Date: date, Payee: payee, Version: 1}
+		config.DB.Create(&tx)
+		assert.NoError(t, search.Index(config.DB, tx.ID))
+		return tx.ID
+	}
+	for i := 0; i < 7; i++ {
+		// pairs of equal dates and amounts exercise the ID tie-breaker
+		ids = append(ids, add(-float64(i/2+1), day.AddDate(0, 0, i/2), "Market"))
+	}
+
+	for _, sort := range []string{"-date", "date", "amount", "-amount", "description"} {
+		got := listAll(t, user.ID, "sort="+sort+"&limit=3")
+		assert.ElementsMatch(t, ids, got, sort)
+	}
+	assert.Equal(t, []uint{ids[6], ids[5], ids[4]}, listAll(t, user.ID, "sort=-date&limit=3")[:3])
+
+	// a transaction added after the first page does not shift the next ones
+	w := serve(user.ID, ListTransactions, http.MethodGet, "/transactions", "/transactions?sort=-date&limit=3", "")
+	var first TransactionPage
+	json.Unmarshal(w.Body.Bytes(), &first)
+	add(-1, day.AddDate(0, 0, 10), "Market")
+	w = serve(user.ID, ListTransactions, http.MethodGet, "/transactions", "/transactions?sort=-date&limit=3&cursor="+url.QueryEscape(*first.NextCursor), "")
+	var second TransactionPage
+	json.Unmarshal(w.Body.Bytes(), &second)
+	assert.Equal(t, []uint{ids[3], ids[2], ids[1]}, []uint{second.Data[0].ID, second.Data[1].ID, second.Data[2].ID})
+
+	// relevance pages by offset
+	got := listAll(t, user.ID, "q=market&limit=3")
+	assert.Len(t, got, len(ids)+1)
+	assert.ElementsMatch(t, got, listAll(t, user.ID, "q=market&limit=100"))
+
+	// a cursor only works with the sort it was issued for
+	w = serve(user.ID, ListTransactions, http.MethodGet, "/transactions", "/transactions?sort=amount&limit=3&cursor="+url.QueryEscape(*first.NextCursor), "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// setupTestDB points config.DB at a fresh database in a temporary directory
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.LoadConfig()
+	config.AppConfig.DBPath = filepath.Join(t.TempDir(), "test.db")
+	config.InitDB()
+	t.Cleanup(func() {
+		if db, err := config.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+}
+
+// createTestUser adds a user with the given email and returns it
+func createTestUser(t *testing.T, email string) models.User {
+	t.Helper()
+	user := models.User{Email: email, PasswordHash: "x"}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// serve runs a request against handler, mounted on route, as the given user
+func serve(userID uint, handler gin.HandlerFunc, method, route, path, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Handle(method, route, func(c *gin.Context) { c.Set("user_id", userID) }, handler)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"expense-tracker/internal/document"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// digestTopTransactions is the number of largest expenses listed in a digest
+const digestTopTransactions = 10
+
+type DigestInput struct {
+	Frequency string `json:"frequency" binding:"required,oneof=weekly monthly"`
+	// Weekday of weekly digests, 0 (Sunday) to 6 (Saturday); default Monday
+	Weekday   *int     `json:"weekday" binding:"omitempty,min=0,max=6"`
+	TimeOfDay string   `json:"time_of_day"`
+	Timezone  string   `json:"timezone"`
+	Sections  []string `json:"sections"`
+	Active    *bool    `json:"active"`
+}
+
+// DigestView is a digest subscription with its sections as a list
+type DigestView struct {
+	models.DigestSubscription
+	Sections []string `json:"sections"`
+}
+
+func digestView(sub models.DigestSubscription) DigestView {
+	return DigestView{DigestSubscription: sub, Sections: sub.SectionList()}
+}
+
+// apply validates the input and copies it onto a digest subscription and
+// schedules the next run. Omitted fields keep their current values; a new
+// subscription defaults to Mondays at 08:00 in the user's timezone with every
+// section.
+func (in DigestInput) apply(user models.User, sub *models.DigestSubscription, now time.Time) error {
+	isNew := sub.ID == 0
+	sub.Frequency = in.Frequency
+	if in.Weekday != nil {
+		sub.Weekday = *in.Weekday
+	} else if isNew {
+		sub.Weekday = int(time.Monday)
+	}
+	if in.TimeOfDay != "" {
+		clock, err := time.Parse("15:04", in.TimeOfDay)
+		if err != nil {
+			return errors.New("Invalid time_of_day format. Use HH:MM.")
+		}
+		sub.TimeOfDay = clock.Format("15:04")
+	} else if isNew {
+		sub.TimeOfDay = "08:00"
+	}
+	if in.Timezone != "" {
+		sub.Timezone = in.Timezone
+	} else if isNew {
+		sub.Timezone = user.Timezone
+	}
+	if sub.Timezone == "" {
+		sub.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(sub.Timezone); err != nil {
+		return errors.New("Unknown timezone " + sub.Timezone)
+	}
+	if in.Sections != nil {
+		if len(in.Sections) == 0 {
+			return errors.New("sections must not be empty")
+		}
+		selected := make(map[string]bool)
+		for _, s := range in.Sections {
+			known := false
+			for _, name := range models.DigestSections {
+				known = known || s == name
+			}
+			if !known {
+				return fmt.Errorf("Unknown section %s. Use one of: %s", s, strings.Join(models.DigestSections, ", "))
+			}
+			selected[s] = true
+		}
+		// keep the display order regardless of the order given
+		var sections []string
+		for _, name := range models.DigestSections {
+			if selected[name] {
+				sections = append(sections, name)
+			}
+		}
+		sub.Sections = strings.Join(sections, ",")
+	} else if isNew {
+		sub.Sections = strings.Join(models.DigestSections, ",")
+	}
+	if in.Active != nil {
+		sub.Active = *in.Active
+	}
+	sub.NextRunAt = sub.NextRun(now)
+	return nil
+}
+
+// summarySection compares income, expenses and net with the previous period
+func summarySection(current, previous PeriodTotals) document.Section {
+	table := document.Table{Columns: []document.Column{
+		{Title: "", Width: 2}, {Title: "This period", Width: 1, Right: true},
+		{Title: "Previous period", Width: 1, Right: true}, {Title: "Change", Width: 1, Right: true},
+	}}
+	row := func(name string, cur, prev float64) {
+		table.Rows = append(table.Rows, []string{name, money(cur), money(prev), money(cur - prev)})
+	}
+	row("Income", current.Income, previous.Income)
+	row("Expenses", current.Expense, previous.Expense)
+	row("Net", current.Net, previous.Net)
+	return document.Section{Heading: "Summary", Table: table}
+}
+
+// largestExpenses lists the largest expenses in [from, to), transfers excluded
+func largestExpenses(userID uint, from, to time.Time) (document.Section, error) {
+	section := document.Section{Heading: "Largest expenses", Empty: "No expenses in this period."}
+	section.Table.Columns = []document.Column{
+		{Title: "Date", Width: 1.4}, {Title: "Category", Width: 2}, {Title: "Payee", Width: 2},
+		{Title: "Description", Width: 3}, {Title: "Amount", Width: 1.4, Right: true},
+	}
+	rows, err := config.DB.Table("transactions t").
+		Select("t.date, c.name, COALESCE(t.payee, ''), COALESCE(t.description, ''), t.amount").
+		Joins("JOIN categories c ON t.category_id = c.id").
+		Where("t.user_id = ? AND t.deleted_at IS NULL AND t.date >= ? AND t.date < ? AND t.amount < 0", userID, from, to).
+		Where("COALESCE(c.kind, '') <> ?", models.CategoryKindTransfer).
+		Order("t.amount, t.date").Limit(digestTopTransactions).Rows()
+	if err != nil {
+		return section, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date time.Time
+		var category, payee, description string
+		var amount float64
+		if err := rows.Scan(&date, &category, &payee, &description, &amount); err != nil {
+			return section, err
+		}
+		section.Table.Rows = append(section.Table.Rows, []string{date.Format("2006-01-02"), category, payee, description, money(amount)})
+	}
+	return section, rows.Err()
+}
+
+// composeDigest builds the digest email of the user for the days [from, to)
+func composeDigest(user models.User, sub models.DigestSubscription, from, to time.Time) (notify.Email, error) {
+	last := to.AddDate(0, 0, -1)
+	title := "Weekly spending digest"
+	if sub.Frequency == models.DigestMonthly {
+		title = "Monthly spending digest"
+	}
+	period := from.Format("Jan 2") + " - " + last.Format("Jan 2, 2006")
+	doc := document.Document{Title: title, Subtitle: user.Email + " - " + period}
+
+	totals, err := categoryTotals(user.ID, from, to, "")
+	if err != nil {
+		return notify.Email{}, err
+	}
+	for _, section := range sub.SectionList() {
+		switch section {
+		case "summary":
+			prevFrom, prevTo := previousPeriod(from, to)
+			previous, err := categoryTotals(user.ID, prevFrom, prevTo, "")
+			if err != nil {
+				return notify.Email{}, err
+			}
+			doc.Sections = append(doc.Sections, summarySection(periodTotals(from, to, totals), periodTotals(prevFrom, prevTo, previous)))
+		case "categories":
+			doc.Sections = append(doc.Sections, categorySection("Spending by category", "No expenses in this period.", totals, func(t categoryTotal) bool {
+				return t.Kind != models.CategoryKindIncome && t.Kind != models.CategoryKindTransfer
+			}))
+		case "budgets":
+			doc.Sections = append(doc.Sections, budgetSection(user.ID, from, to, "No budgets in this period."))
+		case "transactions":
+			section, err := largestExpenses(user.ID, from, to)
+			if err != nil {
+				return notify.Email{}, err
+			}
+			doc.Sections = append(doc.Sections, section)
+		}
+	}
+
+	var html, text bytes.Buffer
+	if err := doc.WriteHTML(&html); err != nil {
+		return notify.Email{}, err
+	}
+	if err := doc.WriteText(&text); err != nil {
+		return notify.Email{}, err
+	}
+	return notify.Email{To: user.Email, Subject: title + ": " + period, Text: text.String(), HTML: html.String()}, nil
+}
+
+// sendDigest composes the digest due at sentAt and hands it to the mailer
+func sendDigest(sub models.DigestSubscription, sentAt time.Time) error {
+	var user models.User
+	if err := config.DB.First(&user, sub.UserID).Error; err != nil {
+		return err
+	}
+	from, to := sub.Period(sentAt)
+	email, err := composeDigest(user, sub, from, to)
+	if err != nil {
+		return err
+	}
+	return notify.CurrentMailer().Send(email)
+}
+
+// SendDueDigests sends the active digests whose next run has passed and
+// schedules their next run. A digest that fails to send is retried on the
+// next call; runs missed while the server was down are not caught up.
+func SendDueDigests(now time.Time) error {
+	var due []models.DigestSubscription
+	if err := config.DB.Where("active = ? AND next_run_at <= ?", true, now).Find(&due).Error; err != nil {
+		return err
+	}
+	for _, sub := range due {
+		if err := sendDigest(sub, sub.NextRunAt); err != nil {
+			log.Printf("failed to send digest %d to user %d: %v", sub.ID, sub.UserID, err)
+			continue
+		}
+		sent := now
+		config.DB.Model(&sub).Updates(map[string]interface{}{"last_sent_at": &sent, "next_run_at": sub.NextRun(now)})
+	}
+	return nil
+}
+
+func findDigest(c *gin.Context) (models.DigestSubscription, bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var sub models.DigestSubscription
+	if err := config.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).First(&sub).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Digest not found"})
+		return sub, false
+	}
+	return sub, true
+}
+
+// ListDigests returns the digest subscriptions of the authenticated user
+// @Summary List digest subscriptions
+// @Description Get the scheduled spending digest emails of the current user
+// @Tags digests
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} DigestView
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /digests [get]
+func ListDigests(c *gin.Context) {
+	var list []models.DigestSubscription
+	if err := config.DB.Where("user_id = ?", c.GetUint("user_id")).Order("id").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	views := make([]DigestView, len(list))
+	for i, sub := range list {
+		views[i] = digestView(sub)
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// CreateDigest subscribes the authenticated user to a spending digest
+// @Summary Create digest subscription
+// @Description Schedule a weekly digest (sent on weekday, covering the previous seven days) or a monthly digest (sent on the 1st, covering the previous month) at time_of_day in timezone. Sections are summary, categories, budgets and transactions; all are included by default. The timezone defaults to the user's timezone.
+// @Tags digests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body DigestInput true "Digest info"
+// @Success 201 {object} DigestView
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /digests [post]
+func CreateDigest(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var input DigestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sub := models.DigestSubscription{UserID: user.ID, Active: true}
+	if err := input.apply(user, &sub, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	active := sub.Active
+	if err := config.DB.Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// gorm replaces a false bool with the column default on insert
+	if !active {
+		config.DB.Model(&sub).Update("active", false)
+	}
+	c.JSON(http.StatusCreated, digestView(sub))
+}
+
+// UpdateDigest updates a digest subscription of the authenticated user
+// @Summary Update digest subscription
+// @Description Update a digest subscription of the current user; omitted fields keep their values and the next run is rescheduled
+// @Tags digests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Digest ID"
+// @Param input body DigestInput true "Digest info"
+// @Success 200 {object} DigestView
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 500 {object} gin.H{"error":string}
+// @Router /digests/{id} [put]
+func UpdateDigest(c *gin.Context) {
+	sub, ok := findDigest(c)
+	if !ok {
+		return
+	}
+	var user models.User
+	config.DB.First(&user, sub.UserID)
+	var input DigestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.apply(user, &sub, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Save(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, digestView(sub))
+}
+
+// DeleteDigest deletes a digest subscription of the authenticated user
+// @Summary Delete digest subscription
+// @Description Unsubscribe the current user from a digest
+// @Tags digests
+// @Security BearerAuth
+// @Param id path int true "Digest ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /digests/{id} [delete]
+func DeleteDigest(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := config.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).Delete(&models.DigestSubscription{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SendDigestNow sends a digest immediately
+// @Summary Send digest now
+// @Description Compose and send the digest for the most recent completed period without changing its schedule, e.g. to preview it
+// @Tags digests
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Digest ID"
+// @Success 200 {object} gin.H{"message":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 502 {object} gin.H{"error":string}
+// @Router /digests/{id}/send [post]
+func SendDigestNow(c *gin.Context) {
+	sub, ok := findDigest(c)
+	if !ok {
+		return
+	}
+	if err := sendDigest(sub, time.Now()); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Digest sent"})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+	"expense-tracker/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestInputApply(t *testing.T) {
+	user := models.User{Timezone: "Europe/Madrid"}
+	now := time.Date(2025, 7, 9, 12, 0, 0, 0, time.UTC)
+
+	var sub models.DigestSubscription
+	assert.NoError(t, DigestInput{Frequency: "weekly"}.apply(user, &sub, now))
+	assert.Equal(t, "08:00", sub.TimeOfDay)
+	assert.Equal(t, "Europe/Madrid", sub.Timezone)
+	assert.Equal(t, 1, sub.Weekday)
+	assert.Equal(t, models.DigestSections, sub.SectionList())
+	// Monday 14 July 08:00 in Madrid
+	assert.Equal(t, time.Date(2025, 7, 14, 6, 0, 0, 0, time.UTC), sub.NextRunAt.UTC())
+
+	sub = models.DigestSubscription{}
+	input := DigestInput{Frequency: "monthly", TimeOfDay: "7:30", Timezone: "UTC", Sections: []string{"budgets", "summary"}}
+	assert.NoError(t, input.apply(user, &sub, now))
+	assert.Equal(t, "07:30", sub.TimeOfDay)
+	assert.Equal(t, []string{"summary", "budgets"}, sub.SectionList())
+	assert.Equal(t, time.Date(2025, 8, 1, 7, 30, 0, 0, time.UTC), sub.NextRunAt)
+
+	// updates keep what they omit
+	sub = models.DigestSubscription{ID: 4, Weekday: 5, TimeOfDay: "18:30", Timezone: "Asia/Tokyo", Sections: "budgets"}
+	assert.NoError(t, DigestInput{Frequency: "weekly"}.apply(user, &sub, now))
+	assert.Equal(t, 5, sub.Weekday)
+	assert.Equal(t, "18:30", sub.TimeOfDay)
+	assert.Equal(t, "Asia/Tokyo", sub.Timezone)
+	assert.Equal(t, []string{"budgets"}, sub.SectionList())
+	sunday := 0
+	assert.NoError(t, DigestInput{Frequency: "weekly", Weekday: &sunday}.apply(user, &sub, now))
+	assert.Equal(t, 0, sub.Weekday)
+
+	assert.Error(t, DigestInput{Frequency: "weekly", TimeOfDay: "25:00"}.apply(user, &sub, now))
+	assert.Error(t, DigestInput{Frequency: "weekly", Timezone: "Mars/Olympus"}.apply(user, &sub, now))
+	assert.Error(t, DigestInput{Frequency: "weekly", Sections: []string{"charts"}}.apply(user, &sub, now))
+	assert.Error(t, DigestInput{Frequency: "weekly", Sections: []string{}}.apply(user, &sub, now))
+}
+
+func TestSummarySection(t *testing.T) {
+	s := summarySection(PeriodTotals{Income: 1000, Expense: -400, Net: 600}, PeriodTotals{Income: 1000, Expense: -500, Net: 500})
+	assert.Equal(t, []string{"Expenses", "-400.00", "-500.00", "100.00"}, s.Table.Rows[1])
+	assert.Equal(t, []string{"Net", "600.00", "500.00", "100.00"}, s.Table.Rows[2])
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AssignInput struct {
+	Assigned float64 `json:"assigned"`
+}
+
+// MoveInput moves money between envelopes. A zero category ID stands for the ready-to-assign pool.
+type MoveInput struct {
+	FromCategoryID uint    `json:"from_category_id"`
+	ToCategoryID   uint    `json:"to_category_id"`
+	Amount         float64 `json:"amount" binding:"required,gt=0"`
+}
+
+type EnvelopeStatus struct {
+	CategoryID   uint    `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Assigned     float64 `json:"assigned"`
+	Activity     float64 `json:"activity"`
+	Available    float64 `json:"available"`
+}
+
+type EnvelopeReport struct {
+	Month         string           `json:"month"`
+	Income        float64          `json:"income"`
+	ReadyToAssign float64          `json:"ready_to_assign"`
+	Envelopes     []EnvelopeStatus `json:"envelopes"`
+}
+
+// requireEnvelopeMode aborts with 400 unless the user budgets in envelope mode
+func requireEnvelopeMode(c *gin.Context) bool {
+	var user models.User
+	if err := config.DB.First(&user, c.GetUint("user_id")).Error; err != nil || user.BudgetMode != models.BudgetModeEnvelope {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Envelope budgeting is not enabled. Set budget_mode to envelope with PUT /me."})
+		return false
+	}
+	return true
+}
+
+// parseMonth validates a YYYY-MM month and returns its first day
+func parseMonth(month string) (time.Time, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return start, errors.New("Invalid month format. Use YYYY-MM.")
+	}
+	return start, nil
+}
+
+// findEnvelope loads an expense category of the user to use as an envelope.
+// Subcategories of an expense category draw from their parent's envelope and
+// have none of their own.
+func findEnvelope(db *gorm.DB, userID, categoryID uint) error {
+	var cat models.Category
+	if err := db.Where("id = ? AND user_id = ?", categoryID, userID).First(&cat).Error; err != nil {
+		return errors.New("Category not found")
+	}
+	if cat.Kind != models.CategoryKindExpense {
+		return errors.New("Only expense categories can be used as envelopes")
+	}
+	if cat.ParentID != nil {
+		var parent models.Category
+		if db.Where("id = ? AND user_id = ?", *cat.ParentID, userID).First(&parent).Error == nil && parent.Kind == models.CategoryKindExpense {
+			return errors.New("Subcategories share the envelope of their parent category")
+		}
+	}
+	return nil
+}
+
+// envelopeIDs maps every expense category to the envelope it draws from.
+// Subcategories of an expense category share their parent's envelope, the
+// same way budgets on a category include its subcategories.
+func envelopeIDs(cats []models.Category) map[uint]uint {
+	kinds := make(map[uint]string)
+	for _, cat := range cats {
+		kinds[cat.ID] = cat.Kind
+	}
+	ids := make(map[uint]uint)
+	for _, cat := range cats {
+		if cat.Kind != models.CategoryKindExpense {
+			continue
+		}
+		ids[cat.ID] = cat.ID
+		if cat.ParentID != nil && kinds[*cat.ParentID] == models.CategoryKindExpense {
+			ids[cat.ID] = *cat.ParentID
+		}
+	}
+	return ids
+}
+
+// byEnvelope adds up per-category sums per envelope
+func byEnvelope(sums map[uint]float64, envelopes map[uint]uint) map[uint]float64 {
+	totals := make(map[uint]float64)
+	for id, sum := range sums {
+		if envelope, ok := envelopes[id]; ok {
+			totals[envelope] += sum
+		}
+	}
+	return totals
+}
+
+// addAssignment changes the amount assigned to an envelope for a month by delta
+func addAssignment(db *gorm.DB, userID, categoryID uint, month string, delta float64) error {
+	a := models.EnvelopeAssignment{UserID: userID, CategoryID: categoryID, Month: month}
+	if err := db.Where(a).FirstOrInit(&a).Error; err != nil {
+		return err
+	}
+	a.Amount += delta
+	return db.Save(&a).Error
+}
+
+// AssignEnvelope sets the amount assigned to an envelope for a month
+// @Summary Assign money to an envelope
+// @Description Set the amount assigned to an expense category for a month (envelope mode only)
+// @Tags envelopes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param month path string true "Month (YYYY-MM)"
+// @Param category_id path int true "Category ID"
+// @Param input body AssignInput true "Assigned amount"
+// @Success 200 {object} models.EnvelopeAssignment
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /envelopes/{month}/{category_id} [put]
+func AssignEnvelope(c *gin.Context) {
+	if !requireEnvelopeMode(c) {
+		return
+	}
+	userID := c.GetUint("user_id")
+	month := c.Param("month")
+	if _, err := parseMonth(month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	categoryID, _ := strconv.Atoi(c.Param("category_id"))
+	var input AssignInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := findEnvelope(config.DB, userID, uint(categoryID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a := models.EnvelopeAssignment{UserID: userID, CategoryID: uint(categoryID), Month: month}
+	if err := config.DB.Where(a).FirstOrInit(&a).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	a.Amount = input.Assigned
+	if err := config.DB.Save(&a).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// MoveEnvelopeMoney moves money between envelopes, or between an envelope and the ready-to-assign pool
+// @Summary Move money between envelopes
+// @Description Move an amount between two envelopes for a month. Use 0 as category ID for the ready-to-assign pool (envelope mode only).
+// @Tags envelopes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param month path string true "Month (YYYY-MM)"
+// @Param input body MoveInput true "Move info"
+// @Success 200 {object} EnvelopeReport
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /envelopes/{month}/move [post]
+func MoveEnvelopeMoney(c *gin.Context) {
+	if !requireEnvelopeMode(c) {
+		return
+	}
+	userID := c.GetUint("user_id")
+	month := c.Param("month")
+	if _, err := parseMonth(month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var input MoveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.FromCategoryID == input.ToCategoryID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination must differ"})
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if input.FromCategoryID != 0 {
+			if err := findEnvelope(tx, userID, input.FromCategoryID); err != nil {
+				return err
+			}
+			if err := addAssignment(tx, userID, input.FromCategoryID, month, -input.Amount); err != nil {
+				return err
+			}
+		}
+		if input.ToCategoryID != 0 {
+			if err := findEnvelope(tx, userID, input.ToCategoryID); err != nil {
+				return err
+			}
+			if err := addAssignment(tx, userID, input.ToCategoryID, month, input.Amount); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := envelopeReport(userID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetEnvelopeReport returns assigned, activity and available per envelope for a month
+// @Summary Get envelope report
+// @Description Returns the ready-to-assign pool and assigned/activity/available per envelope for a month (default: current month). Available amounts, including overspending, carry forward from previous months (envelope mode only).
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param month query string false "Month (YYYY-MM)"
+// @Success 200 {object} EnvelopeReport
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/envelopes [get]
+func GetEnvelopeReport(c *gin.Context) {
+	if !requireEnvelopeMode(c) {
+		return
+	}
+	month := c.DefaultQuery("month", time.Now().UTC().Format("2006-01"))
+	if _, err := parseMonth(month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := envelopeReport(c.GetUint("user_id"), month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// envelopeReport computes the envelope figures for a month. Available is the
+// cumulative assigned amount plus cumulative activity up to the end of the
+// month, so unspent money and overspending both carry forward. Spending in a
+// subcategory draws down its parent's envelope. Ready to assign is all income
+// received so far minus everything assigned so far.
+func envelopeReport(userID uint, month string) (EnvelopeReport, error) {
+	start, err := parseMonth(month)
+	if err != nil {
+		return EnvelopeReport{}, err
+	}
+	end := start.AddDate(0, 1, 0)
+	report := EnvelopeReport{Month: month, Envelopes: []EnvelopeStatus{}}
+
+	assigned, err := sumByCategory(`SELECT category_id, SUM(amount) FROM envelope_assignments WHERE user_id = ? AND month = ? GROUP BY category_id`, userID, month)
+	if err != nil {
+		return report, err
+	}
+	assignedToDate, err := sumByCategory(`SELECT category_id, SUM(amount) FROM envelope_assignments WHERE user_id = ? AND month <= ? GROUP BY category_id`, userID, month)
+	if err != nil {
+		return report, err
+	}
+	activity, err := sumByCategory(`SELECT category_id, SUM(amount) FROM transactions WHERE user_id = ? AND deleted_at IS NULL AND date >= ? AND date < ? GROUP BY category_id`, userID, start, end)
+	if err != nil {
+		return report, err
+	}
+	activityToDate, err := sumByCategory(`SELECT category_id, SUM(amount) FROM transactions WHERE user_id = ? AND deleted_at IS NULL AND date < ? GROUP BY category_id`, userID, end)
+	if err != nil {
+		return report, err
+	}
+
+	var cats []models.Category
+	// trashed categories are included since their transactions still count
+	if err := config.DB.Unscoped().Where("user_id = ?", userID).Order("sort_order, name").Find(&cats).Error; err != nil {
+		return report, err
+	}
+	envelopes := envelopeIDs(cats)
+	envelopeAssigned := byEnvelope(assigned, envelopes)
+	envelopeAssignedToDate := byEnvelope(assignedToDate, envelopes)
+	envelopeActivity := byEnvelope(activity, envelopes)
+	envelopeActivityToDate := byEnvelope(activityToDate, envelopes)
+	var incomeToDate, assignedTotal float64
+	for _, cat := range cats {
+		switch cat.Kind {
+		case models.CategoryKindIncome:
+			report.Income += activity[cat.ID]
+			incomeToDate += activityToDate[cat.ID]
+		case models.CategoryKindExpense:
+			if envelopes[cat.ID] != cat.ID {
+				continue
+			}
+			assignedTotal += envelopeAssignedToDate[cat.ID]
+			available := envelopeAssignedToDate[cat.ID] + envelopeActivityToDate[cat.ID]
+			if (cat.Archived || cat.DeletedAt.Valid) && envelopeAssigned[cat.ID] == 0 && envelopeActivity[cat.ID] == 0 && available == 0 {
+				continue
+			}
+			report.Envelopes = append(report.Envelopes, EnvelopeStatus{
+				CategoryID:   cat.ID,
+				CategoryName: cat.Name,
+				Assigned:     envelopeAssigned[cat.ID],
+				Activity:     envelopeActivity[cat.ID],
+				Available:    available,
+			})
+		}
+	}
+	report.ReadyToAssign = incomeToDate - assignedTotal
+	return report, nil
+}
+
+// sumByCategory runs a query returning (category_id, sum) rows and collects them into a map
+func sumByCategory(query string, args ...interface{}) (map[uint]float64, error) {
+	rows, err := config.DB.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sums := make(map[uint]float64)
+	for rows.Next() {
+		var id uint
+		var sum float64
+		if err := rows.Scan(&id, &sum); err != nil {
+			return nil, err
+		}
+		sums[id] = sum
+	}
+	return sums, rows.Err()
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelopeReport(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "envelopes@example.com")
+	config.DB.Model(&user).Update("budget_mode", models.BudgetModeEnvelope)
+	salary := models.Category{UserID: user.ID, Name: "Salary", Kind: models.CategoryKindIncome}
+	groceries := models.Category{UserID: user.ID, Name: "Groceries", Kind: models.CategoryKindExpense}
+	rent := models.Category{UserID: user.ID, Name: "Rent", Kind: models.CategoryKindExpense}
+	for _, cat := range []*models.Category{&salary, &groceries, &rent} {
+		config.DB.Create(cat)
+	}
+	market := models.Category{UserID: user.ID, Name: "Market", Kind: models.CategoryKindExpense, ParentID: &groceries.ID}
+	config.DB.Create(&market)
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	for _, tx := range []models.Transaction{
+		{CategoryID: salary.ID, Amount: 1000, Date: day("2025-07-01")},
+		{CategoryID: groceries.ID, Amount: -150, Date: day("2025-07-03")},
+		{CategoryID: market.ID, Amount: -200, Date: day("2025-07-10")},
+		{CategoryID: rent.ID, Amount: -400, Date: day("2025-07-01")},
+		{CategoryID: market.ID, Amount: -80, Date: day("2025-08-02")},
+	} {
+		tx.UserID = user.ID
+		config.DB.Create(&tx)
+	}
+	for _, a := range []models.EnvelopeAssignment{
+		{CategoryID: groceries.ID, Month: "2025-07", Amount: 300},
+		{CategoryID: rent.ID, Month: "2025-07", Amount: 500},
+		{CategoryID: groceries.ID, Month: "2025-08", Amount: 100},
+	} {
+		a.UserID = user.ID
+		config.DB.Create(&a)
+	}
+	envelope := func(r EnvelopeReport, id uint) EnvelopeStatus {
+		for _, e := range r.Envelopes {
+			if e.CategoryID == id {
+				return e
+			}
+		}
+		t.Fatalf("no envelope for category %d in %s", id, r.Month)
+		return EnvelopeStatus{}
+	}
+
+	july, err := envelopeReport(user.ID, "2025-07")
+	assert.NoError(t, err)
+	assert.Len(t, july.Envelopes, 2, "subcategories have no envelope of their own")
+	assert.Equal(t, 1000.0, july.Income)
+	assert.Equal(t, 200.0, july.ReadyToAssign)
+	assert.Equal(t, EnvelopeStatus{groceries.ID, "Groceries", 300, -350, -50}, envelope(july, groceries.ID), "spending in Market draws Groceries down")
+	assert.Equal(t, EnvelopeStatus{rent.ID, "Rent", 500, -400, 100}, envelope(july, rent.ID))
+
+	august, _ := envelopeReport(user.ID, "2025-08")
+	assert.Zero(t, august.Income)
+	assert.Equal(t, 100.0, august.ReadyToAssign)
+	assert.Equal(t, EnvelopeStatus{groceries.ID, "Groceries", 100, -80, -30}, envelope(august, groceries.ID), "overspending carries forward")
+	assert.Equal(t, EnvelopeStatus{rent.ID, "Rent", 0, 0, 100}, envelope(august, rent.ID), "unspent money carries forward")
+
+	october, _ := envelopeReport(user.ID, "2025-10")
+	assert.Equal(t, -30.0, envelope(october, groceries.ID).Available)
+	assert.Equal(t, 100.0, october.ReadyToAssign)
+
+	assert.Error(t, findEnvelope(config.DB, user.ID, market.ID))
+	assert.Error(t, findEnvelope(config.DB, user.ID, salary.ID))
+	assert.NoError(t, findEnvelope(config.DB, user.ID, groceries.ID))
+
+	// trashed categories still count
+	config.DB.Delete(&salary)
+	config.DB.Delete(&rent)
+	july, _ = envelopeReport(user.ID, "2025-07")
+	assert.Equal(t, 1000.0, july.Income)
+	assert.Equal(t, 200.0, july.ReadyToAssign)
+	assert.Equal(t, EnvelopeStatus{rent.ID, "Rent", 500, -400, 100}, envelope(july, rent.ID))
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeanStdDev(t *testing.T) {
+	mean, stddev := meanStdDev([]float64{-10, -20, -30})
+	assert.Equal(t, -20.0, mean)
+	assert.Equal(t, 10.0, stddev)
+	mean, stddev = meanStdDev(nil)
+	assert.Zero(t, mean)
+	assert.Zero(t, stddev)
+}
+
+func TestBuildForecast(t *testing.T) {
+	start, _ := time.Parse("2006-01-02", "2025-03-10")
+	items := []ForecastItem{
+		{Date: "2025-03-11", Source: "recurring", Amount: -120},
+		{Date: "2025-03-13", Source: "recurring", Amount: 1000},
+	}
+	resp := buildForecast(start, 100, 4, items, -5, 2)
+	assert.Len(t, resp.Days, 4)
+	assert.Equal(t, "2025-03-11", resp.Days[0].Date)
+	assert.Equal(t, -25.0, resp.Days[0].Expected)
+	assert.InDelta(t, -25-forecastZ*2, resp.Days[0].Lower, 1e-9)
+	assert.Equal(t, 965.0, resp.Days[2].Expected)
+	assert.InDelta(t, 2*forecastZ*2, resp.Days[3].Upper-resp.Days[3].Expected, 1e-9)
+	assert.Equal(t, "2025-03-11", *resp.FirstNegativeDate)
+	assert.Equal(t, -30.0, resp.LowestExpected)
+	assert.Equal(t, "2025-03-12", resp.LowestDate)
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// daysPerMonth converts daily contribution rates to monthly ones
+const daysPerMonth = 365.25 / 12
+
+type GoalInput struct {
+	Name         string  `json:"name" binding:"required"`
+	TargetAmount float64 `json:"target_amount" binding:"required,gt=0"`
+	TargetDate   string  `json:"target_date"`
+	AccountID    *uint   `json:"account_id"`
+	CategoryID   *uint   `json:"category_id"`
+}
+
+type GoalProgress struct {
+	models.Goal
+	CurrentAmount   float64    `json:"current_amount"`
+	Remaining       float64    `json:"remaining"`
+	Percentage      float64    `json:"percentage"`
+	MonthlyRate     float64    `json:"monthly_rate"`
+	ProjectedDate   *time.Time `json:"projected_date"`
+	RequiredMonthly *float64   `json:"required_monthly,omitempty"`
+	OnTrack         *bool      `json:"on_track,omitempty"`
+}
+
+// apply validates the input and copies it onto a goal
+func (in GoalInput) apply(userID uint, g *models.Goal) error {
+	if (in.AccountID == nil) == (in.CategoryID == nil) {
+		return errors.New("Exactly one of account_id or category_id is required")
+	}
+	if err := validateAccount(userID, in.AccountID); err != nil {
+		return err
+	}
+	if in.CategoryID != nil {
+		var count int64
+		config.DB.Model(&models.Category{}).Where("id = ? AND user_id = ?", *in.CategoryID, userID).Count(&count)
+		if count == 0 {
+			return errors.New("Category not found")
+		}
+	}
+	g.Name = in.Name
+	g.TargetAmount = in.TargetAmount
+	g.AccountID = in.AccountID
+	g.CategoryID = in.CategoryID
+	g.TargetDate = nil
+	if in.TargetDate != "" {
+		date, err := time.Parse("2006-01-02", in.TargetDate)
+		if err != nil {
+			return errors.New("Invalid target_date format. Use YYYY-MM-DD.")
+		}
+		g.TargetDate = &date
+	}
+	return nil
+}
+
+// projectCompletion returns when the remaining amount is reached at the given
+// daily contribution rate, or nil when the rate is not positive.
+func projectCompletion(now time.Time, remaining, dailyRate float64) *time.Time {
+	if remaining <= 0 {
+		return &now
+	}
+	if dailyRate <= 0 {
+		return nil
+	}
+	date := now.AddDate(0, 0, int(math.Ceil(remaining/dailyRate)))
+	return &date
+}
+
+// goalProgress computes how far a goal is and when it will be reached. The
+// contribution rate is the average net contribution per day since the first
+// contribution, looking back at most one year.
+func goalProgress(g models.Goal, now time.Time) GoalProgress {
+	progress := GoalProgress{Goal: g}
+	query := config.DB.Model(&models.Transaction{}).Where("user_id = ?", g.UserID)
+	sign := 1.0
+	if g.AccountID != nil {
+		var acc models.Account
+		config.DB.First(&acc, *g.AccountID)
+		progress.CurrentAmount = acc.OpeningBalance
+		query = query.Where("account_id = ?", *g.AccountID)
+	} else if g.CategoryID != nil {
+		// contributions to an expense or transfer category are outflows from spending money
+		var cat models.Category
+		config.DB.Unscoped().First(&cat, *g.CategoryID)
+		if cat.Kind != models.CategoryKindIncome {
+			sign = -1
+		}
+		query = query.Where("category_id = ?", *g.CategoryID)
+	}
+
+	var total float64
+	query.Session(&gorm.Session{}).Select("COALESCE(SUM(amount), 0)").Scan(&total)
+	progress.CurrentAmount += sign * total
+
+	windowStart := now.AddDate(-1, 0, 0)
+	var first models.Transaction
+	if query.Session(&gorm.Session{}).Order("date").First(&first).Error == nil && first.Date.After(windowStart) {
+		windowStart = first.Date
+	}
+	var windowTotal float64
+	query.Session(&gorm.Session{}).Where("date >= ?", windowStart).Select("COALESCE(SUM(amount), 0)").Scan(&windowTotal)
+	days := math.Max(30, now.Sub(windowStart).Hours()/24)
+	dailyRate := sign * windowTotal / days
+
+	progress.MonthlyRate = dailyRate * daysPerMonth
+	progress.Remaining = math.Max(0, g.TargetAmount-progress.CurrentAmount)
+	progress.Percentage = progress.CurrentAmount / g.TargetAmount * 100
+	progress.ProjectedDate = projectCompletion(now, progress.Remaining, dailyRate)
+	if g.TargetDate != nil {
+		months := g.TargetDate.Sub(now).Hours() / 24 / daysPerMonth
+		required := progress.Remaining
+		if months > 1 {
+			required = progress.Remaining / months
+		}
+		onTrack := progress.ProjectedDate != nil && !progress.ProjectedDate.After(*g.TargetDate)
+		progress.RequiredMonthly = &required
+		progress.OnTrack = &onTrack
+	}
+	return progress
+}
+
+// ListGoals returns the savings goals of the authenticated user with their progress
+// @Summary List goals
+// @Description Get the savings goals of the current user with current amount, contribution rate and projected completion date
+// @Tags goals
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} GoalProgress
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /goals [get]
+func ListGoals(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var goals []models.Goal
+	if err := config.DB.Where("user_id = ?", userID).Find(&goals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	list := make([]GoalProgress, 0, len(goals))
+	for _, g := range goals {
+		list = append(list, goalProgress(g, now))
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetGoal returns a savings goal with its progress
+// @Summary Get goal
+// @Description Get a savings goal of the current user with its progress
+// @Tags goals
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Success 200 {object} GoalProgress
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /goals/{id} [get]
+func GetGoal(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	var goal models.Goal
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&goal).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return
+	}
+	c.JSON(http.StatusOK, goalProgress(goal, time.Now().UTC()))
+}
+
+// CreateGoal creates a savings goal for the authenticated user
+// @Summary Create goal
+// @Description Create a savings goal funded by an account or by a category of contributions
+// @Tags goals
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body GoalInput true "Goal info"
+// @Success 201 {object} models.Goal
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /goals [post]
+func CreateGoal(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input GoalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	goal := models.Goal{UserID: userID}
+	if err := input.apply(userID, &goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Create(&goal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, goal)
+}
+
+// UpdateGoal updates a savings goal for the authenticated user
+// @Summary Update goal
+// @Description Update a savings goal of the current user
+// @Tags goals
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Param input body GoalInput true "Goal info"
+// @Success 200 {object} models.Goal
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /goals/{id} [put]
+func UpdateGoal(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	var goal models.Goal
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&goal).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goal not found"})
+		return
+	}
+	var input GoalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.apply(userID, &goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config.DB.Save(&goal)
+	c.JSON(http.StatusOK, goal)
+}
+
+// DeleteGoal deletes a savings goal for the authenticated user
+// @Summary Delete goal
+// @Description Delete a savings goal of the current user
+// @Tags goals
+// @Security BearerAuth
+// @Param id path int true "Goal ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /goals/{id} [delete]
+func DeleteGoal(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Goal{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectCompletion(t *testing.T) {
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	done := projectCompletion(now, 0, 10)
+	assert.Equal(t, now, *done)
+
+	assert.Nil(t, projectCompletion(now, 100, 0))
+	assert.Nil(t, projectCompletion(now, 100, -5))
+
+	date := projectCompletion(now, 100, 3)
+	assert.Equal(t, time.Date(2025, 7, 35, 0, 0, 0, 0, time.UTC), *date)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+type NetWorthPoint struct {
+	Date        string  `json:"date"`
+	Accounts    float64 `json:"accounts"`
+	Assets      float64 `json:"assets"`
+	Liabilities float64 `json:"liabilities"`
+	NetWorth    float64 `json:"net_worth"`
+	Snapshot    bool    `json:"snapshot"`
+}
+
+type NetWorthResponse struct {
+	StartDate string          `json:"start_date"`
+	EndDate   string          `json:"end_date"`
+	Points    []NetWorthPoint `json:"points"`
+}
+
+// netWorthAt computes a user's net worth at the end of a day: account balances
+// plus the latest valuation of each asset, minus the latest outstanding amount
+// of each liability.
+func netWorthAt(userID uint, date time.Time) models.NetWorthSnapshot {
+	next := date.AddDate(0, 0, 1)
+	s := models.NetWorthSnapshot{UserID: userID, Date: date}
+	var opening, sum float64
+	config.DB.Model(&models.Account{}).Where("user_id = ?", userID).Select("COALESCE(SUM(opening_balance), 0)").Scan(&opening)
+	config.DB.Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NOT NULL AND date < ?", userID, next).
+		Select("COALESCE(SUM(amount), 0)").Scan(&sum)
+	s.Accounts = opening + sum
+
+	var values []struct {
+		Kind  string
+		Value float64
+	}
+	config.DB.Raw(`SELECT a.kind, v.value FROM assets a
+		JOIN asset_valuations v ON v.asset_id = a.id
+		WHERE a.user_id = ? AND v.date = (SELECT MAX(date) FROM asset_valuations WHERE asset_id = a.id AND date < ?)`, userID, next).
+		Scan(&values)
+	for _, v := range values {
+		if v.Kind == models.AssetKindLiability {
+			s.Liabilities += v.Value
+		} else {
+			s.Assets += v.Value
+		}
+	}
+	s.NetWorth = s.Accounts + s.Assets - s.Liabilities
+	return s
+}
+
+// netWorthDates returns the month ends from the month of from up to to,
+// ending with to itself when it falls mid-month.
+func netWorthDates(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(to); month = month.AddDate(0, 1, 0) {
+		end := month.AddDate(0, 1, -1)
+		if end.After(to) {
+			end = to
+		}
+		dates = append(dates, end)
+	}
+	return dates
+}
+
+// RecordNetWorthSnapshots stores every user's net worth at the end of the
+// previous month. Existing snapshots are left untouched.
+func RecordNetWorthSnapshots(now time.Time) error {
+	monthEnd := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	var userIDs []uint
+	if err := config.DB.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		var count int64
+		config.DB.Model(&models.NetWorthSnapshot{}).Where("user_id = ? AND date = ?", userID, monthEnd).Count(&count)
+		if count > 0 {
+			continue
+		}
+		snapshot := netWorthAt(userID, monthEnd)
+		if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshot).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetNetWorthReport returns the net worth of the authenticated user over time
+// @Summary Net worth over time
+// @Description Returns the net worth at each month end in the range (and at end_date when it falls mid-month): account balances plus assets minus liabilities. Month ends with a recorded snapshot use it, so history stays stable after edits; other points are computed from current data.
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param start_date query string false "Start date (YYYY-MM-DD, default: 11 months before end_date)"
+// @Param end_date query string false "End date (YYYY-MM-DD, default: today)"
+// @Param tz query string false "IANA timezone (default: the user's timezone)"
+// @Success 200 {object} NetWorthResponse
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/net-worth [get]
+func GetNetWorthReport(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to := today(loc)
+	if end := c.Query("end_date"); end != "" {
+		if to, err = time.Parse("2006-01-02", end); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD."})
+			return
+		}
+	}
+	from := time.Date(to.Year(), to.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	if start := c.Query("start_date"); start != "" {
+		if from, err = time.Parse("2006-01-02", start); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD."})
+			return
+		}
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must not be after end_date"})
+		return
+	}
+
+	var snapshots []models.NetWorthSnapshot
+	config.DB.Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).Find(&snapshots)
+	recorded := make(map[string]models.NetWorthSnapshot)
+	for _, s := range snapshots {
+		recorded[s.Date.Format("2006-01-02")] = s
+	}
+	resp := NetWorthResponse{StartDate: from.Format("2006-01-02"), EndDate: to.Format("2006-01-02"), Points: []NetWorthPoint{}}
+	for _, date := range netWorthDates(from, to) {
+		s, ok := recorded[date.Format("2006-01-02")]
+		if !ok {
+			s = netWorthAt(userID, date)
+		}
+		resp.Points = append(resp.Points, NetWorthPoint{
+			Date:        date.Format("2006-01-02"),
+			Accounts:    s.Accounts,
+			Assets:      s.Assets,
+			Liabilities: s.Liabilities,
+			NetWorth:    s.NetWorth,
+			Snapshot:    ok,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
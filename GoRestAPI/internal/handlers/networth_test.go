@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetWorthDates(t *testing.T) {
+	d := func(s string) time.Time {
+		v, _ := time.Parse("2006-01-02", s)
+		return v
+	}
+	dates := netWorthDates(d("2025-01-15"), d("2025-04-10"))
+	var got []string
+	for _, date := range dates {
+		got = append(got, date.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-10"}, got)
+	assert.Len(t, netWorthDates(d("2025-06-30"), d("2025-06-30")), 1)
+}
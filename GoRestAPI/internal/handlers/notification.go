@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/notify"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookInput struct {
+	URL string `json:"url" binding:"required,url"`
+}
+
+// CreatedWebhook is a new webhook with its signing secret, which is not returned again
+type CreatedWebhook struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// ListNotifications returns the in-app notifications of the authenticated user
+// @Summary List notifications
+// @Description Get the notification inbox of the current user, newest first
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {array} models.Notification
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /notifications [get]
+func ListNotifications(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var notifications []models.Notification
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read = ?", false)
+	}
+	if err := query.Order("created_at desc").Limit(100).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkNotificationRead marks a notification as read
+// @Summary Mark notification read
+// @Description Mark a notification of the current user as read
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /notifications/{id}/read [post]
+func MarkNotificationRead(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	result := config.DB.Model(&models.Notification{}).Where("id = ? AND user_id = ?", id, userID).Update("read", true)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllNotificationsRead marks every notification of the authenticated user as read
+// @Summary Mark all notifications read
+// @Description Mark all notifications of the current user as read
+// @Tags notifications
+// @Security BearerAuth
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /notifications/read-all [post]
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if err := config.DB.Model(&models.Notification{}).Where("user_id = ? AND read = ?", userID, false).Update("read", true).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhooks returns the webhooks of the authenticated user
+// @Summary List webhooks
+// @Description Get the outgoing notification webhooks of the current user. Secrets are only returned when a webhook is created.
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /webhooks [get]
+func ListWebhooks(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var hooks []models.Webhook
+	if err := config.DB.Where("user_id = ?", userID).Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateWebhook registers a webhook for the authenticated user
+// @Summary Create webhook
+// @Description Register an https URL that receives notifications as signed JSON POST requests. URLs on loopback, private or link-local addresses are rejected and redirects are not followed. The generated secret signs the X-Webhook-Signature header; store it, since it is only returned here.
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body WebhookInput true "Webhook info"
+// @Success 201 {object} CreatedWebhook
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := notify.ValidateWebhookURL(input.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	hook := models.Webhook{UserID: userID, URL: input.URL, Secret: hex.EncodeToString(secret), Active: true}
+	if err := config.DB.Create(&hook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, CreatedWebhook{hook, hook.Secret})
+}
+
+// DeleteWebhook removes a webhook of the authenticated user
+// @Summary Delete webhook
+// @Description Delete a webhook of the current user
+// @Tags notifications
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Webhook{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
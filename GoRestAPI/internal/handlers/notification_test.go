@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSecretOnlyOnCreate(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "hooks@example.com")
+
+	w := serve(user.ID, CreateWebhook, http.MethodPost, "/webhooks", "/webhooks", `{"url":"http://127.0.0.1:8080/hook"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(user.ID, CreateWebhook, http.MethodPost, "/webhooks", "/webhooks", `{"url":"https://example.com/hook"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Len(t, created["secret"], 64)
+
+	w = serve(user.ID, ListWebhooks, http.MethodGet, "/webhooks", "/webhooks", "")
+	var hooks []map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &hooks)
+	assert.Len(t, hooks, 1)
+	assert.Equal(t, "https://example.com/hook", hooks[0]["url"])
+	assert.NotContains(t, hooks[0], "secret")
+}
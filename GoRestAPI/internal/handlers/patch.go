@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// errVersionConflict is returned when a row changed between reading and writing it
+var errVersionConflict = errors.New("The resource was modified by someone else; fetch it again and retry")
+
+// etag is the entity tag of a versioned resource
+func etag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatch checks the If-Match header against the current version of a
+// resource. Without the header any version matches. On a mismatch it
+// responds 412 and returns false.
+func ifMatch(c *gin.Context, version uint) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// If-Match uses strong comparison, so weak tags never match
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	preconditionFailed(c, version)
+	return false
+}
+
+// preconditionFailed responds 412 with the current ETag
+func preconditionFailed(c *gin.Context, version uint) {
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": errVersionConflict.Error()})
+}
+
+// nullableUint is an optional JSON number that tells an omitted field (Set is
+// false) from an explicit null (Set is true and Value nil), like a merge patch
+type nullableUint struct {
+	Set   bool
+	Value *uint
+}
+
+func (n *nullableUint) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
+
+func (n nullableUint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Value)
+}
+
+// mergePatch applies a JSON Merge Patch (RFC 7386) to a decoded JSON document:
+// objects merge recursively, null removes a member and anything else replaces it
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// bindMergePatch applies the merge patch in the request body to current, the
+// input form of the resource, and validates the result like a full PUT body
+func bindMergePatch(c *gin.Context, current interface{}) error {
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" && ct != "" {
+		return errors.New("Content-Type must be application/merge-patch+json")
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return errors.New("Invalid JSON: " + err.Error())
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return errors.New("A merge patch must be a JSON object")
+	}
+	data, _ := json.Marshal(current)
+	var doc interface{}
+	json.Unmarshal(data, &doc)
+	merged, _ := json.Marshal(mergePatch(doc, patch))
+	// start from zero values so that removed members do not keep their old value
+	v := reflect.ValueOf(current).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := json.Unmarshal(merged, current); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(current)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7386, appendix A
+	cases := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		var target, patch interface{}
+		json.Unmarshal([]byte(tc.target), &target)
+		json.Unmarshal([]byte(tc.patch), &patch)
+		got, _ := json.Marshal(mergePatch(target, patch))
+		assert.JSONEq(t, tc.want, string(got), tc.patch)
+	}
+}
+
+func patchContext(body, contentType, ifMatch string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+	if ifMatch != "" {
+		c.Request.Header.Set("If-Match", ifMatch)
+	}
+	return c, w
+}
+
+func TestIfMatch(t *testing.T) {
+	for header, ok := range map[string]bool{
+		"":         true,
+		"*":        true,
+		`"3"`:      true,
+		`"1", "3"`: true,
+		`"2"`:      false,
+		`W/"3"`:    false,
+		`3`:        false,
+	} {
+		c, w := patchContext("", "", header)
+		assert.Equal(t, ok, ifMatch(c, 3), header)
+		if !ok {
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+			assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestBindMergePatch(t *testing.T) {
+	account := uint(2)
+	current := TransactionInput{Amount: -20, Date: "2025-08-03", CategoryID: 5, AccountID: &account, Payee: "Shop", Notes: "n", Tags: []string{"food"}}
+
+	in := current
+	c, _ := patchContext(`{"payee":"Corner Market","account_id":null,"tags":null}`, "application/merge-patch+json", "")
+	assert.NoError(t, bindMergePatch(c, &in))
+	assert.Equal(t, "Corner Market", in.Payee)
+	assert.Nil(t, in.AccountID)
+	assert.Nil(t, in.Tags)
+	assert.Equal(t, -20.0, in.Amount)
+	assert.Equal(t, "n", in.Notes)
+
+	in = current
+	c, _ = patchContext(`{"amount":null}`, "application/json", "")
+	assert.Error(t, bindMergePatch(c, &in), "required fields cannot be removed")
+
+	for body, contentType := range map[string]string{
+		`[]`:            "application/merge-patch+json",
+		`{"payee":`:     "application/merge-patch+json",
+		`{"payee":"x"}`: "text/plain",
+	} {
+		in = current
+		c, _ = patchContext(body, contentType, "")
+		assert.Error(t, bindMergePatch(c, &in), body)
+	}
+}
@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	pivotMaxDimensions = 3
+	pivotDefaultLimit  = 1000
+	pivotMaxLimit      = 10000
+)
+
+// pivotDimension is a whitelisted grouping column and the join it needs
+type pivotDimension struct {
+	expr string
+	join string
+}
+
+// Joins used by dimensions. Categories are always joined as c.
+var pivotJoins = map[string]string{
+	"parent":  "LEFT JOIN categories pc ON pc.id = c.parent_id",
+	"account": "LEFT JOIN accounts a ON a.id = t.account_id",
+	"tag":     "LEFT JOIN transaction_tags tt ON tt.transaction_id = t.id LEFT JOIN tags tg ON tg.id = tt.tag_id",
+}
+
+var pivotDimensions = map[string]pivotDimension{
+	"category":        {"c.name", ""},
+	"parent_category": {"COALESCE(pc.name, c.name)", "parent"},
+	"tag":             {"COALESCE(tg.name, '')", "tag"},
+	"account":         {"COALESCE(a.name, '')", "account"},
+	"payee":           {"COALESCE(t.payee, '')", ""},
+	"day":             {"strftime('%Y-%m-%d', t.date)", ""},
+	"week":            {"date(t.date, '-6 days', 'weekday 1')", ""},
+	"month":           {"strftime('%Y-%m', t.date)", ""},
+	"year":            {"strftime('%Y', t.date)", ""},
+}
+
+var pivotMeasures = map[string]string{
+	"sum":   "SUM(t.amount)",
+	"count": "COUNT(*)",
+	"avg":   "AVG(t.amount)",
+	"min":   "MIN(t.amount)",
+	"max":   "MAX(t.amount)",
+}
+
+// PivotSpec is a validated pivot query
+type PivotSpec struct {
+	Dimensions []string
+	Measures   []string
+	// Sort is a selected dimension or measure, prefixed with - for descending order
+	Sort  string
+	Limit int
+
+	From, To   *time.Time
+	CategoryID *uint
+	AccountID  *uint
+	Tag        string
+	Payee      string
+	Kind       string
+	MinAmount  *float64
+	MaxAmount  *float64
+}
+
+type PivotResponse struct {
+	Dimensions []string                 `json:"dimensions"`
+	Measures   []string                 `json:"measures"`
+	Rows       []map[string]interface{} `json:"rows"`
+	Totals     map[string]interface{}   `json:"totals"`
+	Truncated  bool                     `json:"truncated"`
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parsePivotSpec validates the query parameters of a pivot request
+func parsePivotSpec(q url.Values) (PivotSpec, error) {
+	spec := PivotSpec{Dimensions: splitList(q.Get("dimensions")), Measures: splitList(q.Get("measures")), Limit: pivotDefaultLimit}
+	if len(spec.Measures) == 0 {
+		spec.Measures = []string{"sum"}
+	}
+	if len(spec.Dimensions) > pivotMaxDimensions {
+		return spec, fmt.Errorf("At most %d dimensions are allowed", pivotMaxDimensions)
+	}
+	seen := make(map[string]bool)
+	for _, d := range spec.Dimensions {
+		if _, ok := pivotDimensions[d]; !ok {
+			return spec, errors.New("Unknown dimension " + d + ". Use category, parent_category, tag, account, payee, day, week, month or year.")
+		}
+		if seen[d] {
+			return spec, errors.New("Duplicate dimension " + d)
+		}
+		seen[d] = true
+	}
+	for _, m := range spec.Measures {
+		if _, ok := pivotMeasures[m]; !ok {
+			return spec, errors.New("Unknown measure " + m + ". Use sum, count, avg, min or max.")
+		}
+		if seen[m] {
+			return spec, errors.New("Duplicate measure " + m)
+		}
+		seen[m] = true
+	}
+	if sort := q.Get("sort"); sort != "" {
+		if !seen[strings.TrimPrefix(sort, "-")] {
+			return spec, errors.New("sort must be one of the selected dimensions or measures")
+		}
+		spec.Sort = sort
+	}
+	if l := q.Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 || v > pivotMaxLimit {
+			return spec, fmt.Errorf("limit must be between 1 and %d", pivotMaxLimit)
+		}
+		spec.Limit = v
+	}
+
+	if start := q.Get("start_date"); start != "" {
+		v, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return spec, errors.New("Invalid start_date format. Use YYYY-MM-DD.")
+		}
+		spec.From = &v
+	}
+	if end := q.Get("end_date"); end != "" {
+		v, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return spec, errors.New("Invalid end_date format. Use YYYY-MM-DD.")
+		}
+		v = v.AddDate(0, 0, 1)
+		spec.To = &v
+	}
+	if spec.From != nil && spec.To != nil && !spec.From.Before(*spec.To) {
+		return spec, errors.New("start_date must not be after end_date")
+	}
+	for name, target := range map[string]**uint{"category_id": &spec.CategoryID, "account_id": &spec.AccountID} {
+		if v := q.Get(name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return spec, errors.New("Invalid " + name)
+			}
+			u := uint(id)
+			*target = &u
+		}
+	}
+	for name, target := range map[string]**float64{"min_amount": &spec.MinAmount, "max_amount": &spec.MaxAmount} {
+		if v := q.Get(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return spec, errors.New("Invalid " + name)
+			}
+			*target = &f
+		}
+	}
+	spec.Tag = strings.ToLower(strings.TrimSpace(q.Get("tag")))
+	spec.Payee = q.Get("payee")
+	spec.Kind = q.Get("kind")
+	switch spec.Kind {
+	case "", models.CategoryKindIncome, models.CategoryKindExpense, models.CategoryKindTransfer:
+	default:
+		return spec, errors.New("kind must be income, expense or transfer")
+	}
+	return spec, nil
+}
+
+// SQL compiles the spec into a parameterized query over the user's
+// transactions. Only whitelisted expressions reach the SQL text; every
+// filter value is a parameter. With grouped false the query returns the
+// measures over all matching transactions, each counted once whatever its
+// tags. categoryIDs are the category filter and its subcategories.
+func (s PivotSpec) SQL(userID uint, categoryIDs []uint, grouped bool) (string, []interface{}) {
+	var selects, groups, joins []string
+	joined := make(map[string]bool)
+	if grouped {
+		for i, d := range s.Dimensions {
+			dim := pivotDimensions[d]
+			selects = append(selects, fmt.Sprintf("%s AS %q", dim.expr, d))
+			groups = append(groups, strconv.Itoa(i+1))
+			if dim.join != "" && !joined[dim.join] {
+				joined[dim.join] = true
+				joins = append(joins, pivotJoins[dim.join])
+			}
+		}
+	}
+	for _, m := range s.Measures {
+		selects = append(selects, fmt.Sprintf("%s AS %q", pivotMeasures[m], m))
+	}
+
+	where := []string{"t.user_id = ?", "t.deleted_at IS NULL"}
+	args := []interface{}{userID}
+	add := func(cond string, values ...interface{}) {
+		where = append(where, cond)
+		args = append(args, values...)
+	}
+	if s.From != nil {
+		add("t.date >= ?", *s.From)
+	}
+	if s.To != nil {
+		add("t.date < ?", *s.To)
+	}
+	if s.CategoryID != nil {
+		add("t.category_id IN ?", categoryIDs)
+	}
+	if s.AccountID != nil {
+		add("t.account_id = ?", *s.AccountID)
+	}
+	if s.Tag != "" {
+		add("t.id IN (SELECT ftt.transaction_id FROM transaction_tags ftt JOIN tags ftg ON ftg.id = ftt.tag_id WHERE ftg.name = ?)", s.Tag)
+	}
+	if s.Payee != "" {
+		add("LOWER(t.payee) = LOWER(?)", s.Payee)
+	}
+	switch s.Kind {
+	case models.CategoryKindExpense:
+		add("COALESCE(c.kind, '') IN ('expense', '')")
+	case models.CategoryKindIncome, models.CategoryKindTransfer:
+		add("c.kind = ?", s.Kind)
+	}
+	if s.MinAmount != nil {
+		add("t.amount >= ?", *s.MinAmount)
+	}
+	if s.MaxAmount != nil {
+		add("t.amount <= ?", *s.MaxAmount)
+	}
+
+	query := "SELECT " + strings.Join(selects, ", ") + " FROM transactions t JOIN categories c ON c.id = t.category_id"
+	if len(joins) > 0 {
+		query += " " + strings.Join(joins, " ")
+	}
+	query += " WHERE " + strings.Join(where, " AND ")
+	if !grouped || len(groups) == 0 {
+		return query, args
+	}
+	query += " GROUP BY " + strings.Join(groups, ", ")
+	order := groups
+	if s.Sort != "" {
+		direction := "ASC"
+		if strings.HasPrefix(s.Sort, "-") {
+			direction = "DESC"
+		}
+		order = append([]string{fmt.Sprintf("%q %s", strings.TrimPrefix(s.Sort, "-"), direction)}, groups...)
+	}
+	query += " ORDER BY " + strings.Join(order, ", ")
+	// one more row than the limit tells whether the result was truncated
+	query += " LIMIT ?"
+	args = append(args, s.Limit+1)
+	return query, args
+}
+
+// runPivot executes a compiled query and returns one map per row
+func runPivot(query string, args []interface{}, dimensions, measures []string) ([]map[string]interface{}, error) {
+	rows, err := config.DB.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		dims := make([]sql.NullString, len(dimensions))
+		values := make([]sql.NullFloat64, len(measures))
+		dest := make([]interface{}, 0, len(dims)+len(values))
+		for i := range dims {
+			dest = append(dest, &dims[i])
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(dest))
+		for i, d := range dimensions {
+			row[d] = dims[i].String
+		}
+		for i, m := range measures {
+			switch {
+			case m == "count":
+				row[m] = int64(values[i].Float64)
+			case values[i].Valid:
+				row[m] = values[i].Float64
+			default:
+				row[m] = nil
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// GetPivotReport aggregates the authenticated user's transactions by arbitrary dimensions
+// @Summary Pivot report
+// @Description Groups transactions by up to three dimensions (category, parent_category, tag, account, payee, day, week, month, year) and computes the chosen measures (sum, count, avg, min, max) of their amounts. A transaction with several tags counts once per tag when grouping by tag; untagged transactions, transactions without account and without payee are grouped under an empty string. Totals are computed over all matching transactions.
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param dimensions query string false "Comma-separated dimensions"
+// @Param measures query string false "Comma-separated measures (default: sum)"
+// @Param sort query string false "A selected dimension or measure, prefixed with - for descending order (default: by dimensions)"
+// @Param limit query int false "Maximum number of rows (default: 1000, max 10000)"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param category_id query int false "Category ID, including subcategories"
+// @Param account_id query int false "Account ID"
+// @Param tag query string false "Tag"
+// @Param payee query string false "Payee (case-insensitive)"
+// @Param kind query string false "Category kind: income, expense or transfer"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Success 200 {object} PivotResponse
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/pivot [get]
+func GetPivotReport(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	spec, err := parsePivotSpec(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var categoryIDs []uint
+	if spec.CategoryID != nil {
+		categoryIDs = categoryGroupIDs(userID, *spec.CategoryID)
+	}
+	resp := PivotResponse{Dimensions: spec.Dimensions, Measures: spec.Measures}
+	if resp.Dimensions == nil {
+		resp.Dimensions = []string{}
+	}
+	query, args := spec.SQL(userID, categoryIDs, true)
+	if resp.Rows, err = runPivot(query, args, spec.Dimensions, spec.Measures); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(resp.Rows) > spec.Limit {
+		resp.Rows, resp.Truncated = resp.Rows[:spec.Limit], true
+	}
+	query, args = spec.SQL(userID, categoryIDs, false)
+	totals, err := runPivot(query, args, nil, spec.Measures)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp.Totals = totals[0]
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePivotSpec(t *testing.T) {
+	spec, err := parsePivotSpec(url.Values{"dimensions": {"tag, month"}, "measures": {"sum,count"}, "sort": {"-sum"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tag", "month"}, spec.Dimensions)
+	assert.Equal(t, []string{"sum", "count"}, spec.Measures)
+	assert.Equal(t, pivotDefaultLimit, spec.Limit)
+
+	spec, err = parsePivotSpec(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sum"}, spec.Measures)
+
+	for _, q := range []url.Values{
+		{"dimensions": {"category; DROP TABLE users"}},
+		{"dimensions": {"month,month"}},
+		{"dimensions": {"category,tag,account,month"}},
+		{"measures": {"median"}},
+		{"dimensions": {"month"}, "sort": {"payee"}},
+		{"limit": {"0"}},
+		{"kind": {"savings"}},
+		{"start_date": {"2025-08-01"}, "end_date": {"2025-07-01"}},
+		{"min_amount": {"ten"}},
+	} {
+		_, err := parsePivotSpec(q)
+		assert.Error(t, err, q.Encode())
+	}
+}
+
+func TestPivotSQL(t *testing.T) {
+	spec, err := parsePivotSpec(url.Values{
+		"dimensions": {"parent_category,tag,month"},
+		"measures":   {"sum,avg"},
+		"sort":       {"-sum"},
+		"payee":      {"x' OR 1=1 --"},
+		"kind":       {"expense"},
+		"start_date": {"2025-01-01"},
+	})
+	assert.NoError(t, err)
+	query, args := spec.SQL(7, nil, true)
+	assert.Contains(t, query, `COALESCE(pc.name, c.name) AS "parent_category"`)
+	assert.Contains(t, query, "LEFT JOIN tags tg")
+	assert.Contains(t, query, "GROUP BY 1, 2, 3 ORDER BY \"sum\" DESC, 1, 2, 3 LIMIT ?")
+	// values are parameters, never part of the SQL text
+	assert.NotContains(t, query, "OR 1=1")
+	assert.Equal(t, uint(7), args[0])
+	assert.Contains(t, args, "x' OR 1=1 --")
+	assert.Equal(t, spec.Limit+1, args[len(args)-1])
+	assert.Equal(t, strings.Count(query, "?"), len(args))
+
+	// totals neither group nor join tags, so tagged transactions count once
+	query, args = spec.SQL(7, nil, false)
+	assert.NotContains(t, query, "GROUP BY")
+	assert.NotContains(t, query, "transaction_tags")
+	assert.Equal(t, strings.Count(query, "?"), len(args))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" Travel", "food", "travel "})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"food", "travel"}, tags)
+	_, err = normalizeTags([]string{" "})
+	assert.Error(t, err)
+	tags, _ = normalizeTags(nil)
+	assert.Equal(t, []string{}, tags)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+type RecurringInput struct {
+	Amount      float64 `json:"amount" binding:"required"`
+	CategoryID  uint    `json:"category_id" binding:"required"`
+	AccountID   *uint   `json:"account_id"`
+	Payee       string  `json:"payee"`
+	Description string  `json:"description"`
+	Frequency   string  `json:"frequency" binding:"required,oneof=weekly biweekly monthly quarterly yearly"`
+	StartDate   string  `json:"start_date" binding:"required"`
+	EndDate     string  `json:"end_date"`
+	Active      *bool   `json:"active"`
+}
+
+// apply validates the input and copies it onto a recurring transaction
+func (in RecurringInput) apply(userID uint, r *models.RecurringTransaction) error {
+	if err := validateCategory(userID, in.CategoryID, in.Amount, false); err != nil {
+		return err
+	}
+	if err := validateAccount(userID, in.AccountID); err != nil {
+		return err
+	}
+	start, err := time.Parse("2006-01-02", in.StartDate)
+	if err != nil {
+		return errors.New("Invalid start_date format. Use YYYY-MM-DD.")
+	}
+	r.EndDate = nil
+	if in.EndDate != "" {
+		end, err := time.Parse("2006-01-02", in.EndDate)
+		if err != nil {
+			return errors.New("Invalid end_date format. Use YYYY-MM-DD.")
+		}
+		if end.Before(start) {
+			return errors.New("end_date must not be before start_date")
+		}
+		r.EndDate = &end
+	}
+	r.Amount = in.Amount
+	r.CategoryID = in.CategoryID
+	r.AccountID = in.AccountID
+	r.Payee = in.Payee
+	r.Description = in.Description
+	r.Frequency = in.Frequency
+	r.StartDate = start
+	if in.Active != nil {
+		r.Active = *in.Active
+	}
+	return nil
+}
+
+// ListRecurring returns the recurring transactions of the authenticated user
+// @Summary List recurring transactions
+// @Description Get the recurring transaction templates of the current user
+// @Tags recurring
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.RecurringTransaction
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /recurring [get]
+func ListRecurring(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var list []models.RecurringTransaction
+	if err := config.DB.Where("user_id = ?", userID).Order("start_date").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateRecurring creates a recurring transaction for the authenticated user
+// @Summary Create recurring transaction
+// @Description Create a transaction template that repeats weekly, biweekly, monthly, quarterly or yearly from start_date
+// @Tags recurring
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body RecurringInput true "Recurring transaction info"
+// @Success 201 {object} models.RecurringTransaction
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /recurring [post]
+func CreateRecurring(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input RecurringInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	r := models.RecurringTransaction{UserID: userID, Active: true}
+	if err := input.apply(userID, &r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Create(&r).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, r)
+}
+
+// UpdateRecurring updates a recurring transaction for the authenticated user
+// @Summary Update recurring transaction
+// @Description Update a recurring transaction template of the current user
+// @Tags recurring
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Recurring transaction ID"
+// @Param input body RecurringInput true "Recurring transaction info"
+// @Success 200 {object} models.RecurringTransaction
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /recurring/{id} [put]
+func UpdateRecurring(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	var r models.RecurringTransaction
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&r).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring transaction not found"})
+		return
+	}
+	var input RecurringInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.apply(userID, &r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config.DB.Save(&r)
+	c.JSON(http.StatusOK, r)
+}
+
+// DeleteRecurring deletes a recurring transaction for the authenticated user
+// @Summary Delete recurring transaction
+// @Description Delete a recurring transaction template of the current user. Transactions already recorded are kept.
+// @Tags recurring
+// @Security BearerAuth
+// @Param id path int true "Recurring transaction ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /recurring/{id} [delete]
+func DeleteRecurring(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.RecurringTransaction{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,49 +1,423 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
+	"expense-tracker/internal/models"
 	"expense-tracker/internal/config"
+	"expense-tracker/internal/rollup"
 	"github.com/gin-gonic/gin"
 )
 
 type SummaryResponse struct {
 	TotalIncome   float64            `json:"total_income"`
 	TotalExpense  float64            `json:"total_expense"`
+	Net           float64            `json:"net"`
 	ByCategory    map[string]float64 `json:"by_category"`
+	StartDate     string             `json:"start_date,omitempty"`
+	EndDate       string             `json:"end_date,omitempty"`
+	GroupBy       string             `json:"group_by,omitempty"`
+	Timezone      string             `json:"timezone"`
+	Series        []SummaryBucket    `json:"series,omitempty"`
 }
 
-// GetSummary returns monthly totals and category breakdown for the authenticated user
-// @Summary Get monthly totals and category breakdown
-// @Description Returns total income, total expense, and a breakdown by category for the current user
+type SummaryBucket struct {
+	Period     string             `json:"period"`
+	StartDate  string             `json:"start_date"`
+	EndDate    string             `json:"end_date"`
+	Income     float64            `json:"income"`
+	Expense    float64            `json:"expense"`
+	Net        float64            `json:"net"`
+	ByCategory map[string]float64 `json:"by_category"`
+}
+
+// defaultBuckets is how many buckets a grouped summary covers when no date range is given
+var defaultBuckets = map[string]int{"day": 30, "week": 12, "month": 12, "quarter": 8, "year": 5}
+
+// bucketStart returns the start of the group_by bucket containing the calendar date d.
+// Weeks start on Monday.
+func bucketStart(d time.Time, groupBy string) time.Time {
+	y, m, day := d.Date()
+	switch groupBy {
+	case "day":
+		return time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+	case "week":
+		return time.Date(y, m, day-(int(d.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		return time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// bucketNext returns the start of the bucket following the one starting at start
+func bucketNext(start time.Time, groupBy string) time.Time {
+	switch groupBy {
+	case "day":
+		return start.AddDate(0, 0, 1)
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "quarter":
+		return start.AddDate(0, 3, 0)
+	case "year":
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// bucketLabel names a bucket, e.g. 2025-07-19, 2025-W29, 2025-07, 2025-Q3 or 2025
+func bucketLabel(start time.Time, groupBy string) string {
+	switch groupBy {
+	case "day":
+		return start.Format("2006-01-02")
+	case "week":
+		y, w := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case "quarter":
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case "year":
+		return start.Format("2006")
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// userLocation resolves the timezone for a request: the tz query parameter,
+// then the user's saved timezone, then UTC.
+func userLocation(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		var user models.User
+		config.DB.First(&user, c.GetUint("user_id"))
+		name = user.Timezone
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("Unknown timezone " + name)
+	}
+	return loc, nil
+}
+
+// today returns the current calendar date in loc, as a UTC midnight like stored transaction dates
+func today(loc *time.Location) time.Time {
+	y, m, d := time.Now().In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// bucketSQL returns the SQL expression for the start of the group_by bucket
+// containing the date in column, as YYYY-MM-DD; it matches bucketStart.
+// Without group_by all rows fall into one unnamed bucket.
+func bucketSQL(column, groupBy string) string {
+	switch groupBy {
+	case "day":
+		return "date(" + column + ")"
+	case "week":
+		return "date(" + column + ", '-' || ((CAST(strftime('%w', " + column + ") AS INTEGER) + 6) % 7) || ' days')"
+	case "month":
+		return "strftime('%Y-%m-01', " + column + ")"
+	case "quarter":
+		return "printf('%s-%02d-01', strftime('%Y', " + column + "), (CAST(strftime('%m', " + column + ") AS INTEGER) - 1) / 3 * 3 + 1)"
+	case "year":
+		return "strftime('%Y-01-01', " + column + ")"
+	default:
+		return "''"
+	}
+}
+
+// useRollups reports whether a summary can be read from the monthly rollups:
+// the range and every bucket must be made of whole months and no account filter applies
+func useRollups(from, to *time.Time, groupBy, accountID string) bool {
+	if accountID != "" || groupBy == "day" || groupBy == "week" {
+		return false
+	}
+	return (from == nil || rollup.Month(*from).Equal(*from)) && (to == nil || rollup.Month(*to).Equal(*to))
+}
+
+// GetSummary returns income, expense and category totals for the authenticated user
+// @Summary Get totals and category breakdown
+// @Description Returns total income, total expense, net and a breakdown by category for the current user, optionally restricted to a date range, category (including subcategories) or account. With group_by the response also contains a time series with one bucket per period; without a date range it covers the last periods up to today in the user's timezone. Transfer categories are excluded from income and expense totals.
 // @Tags reports
 // @Security BearerAuth
 // @Produce json
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param category_id query int false "Category ID"
+// @Param account query string false "Account ID or name"
+// @Param account_id query int false "Account ID (same as account)"
+// @Param group_by query string false "Bucket size: day, week, month, quarter or year"
+// @Param tz query string false "IANA timezone (default: the user's timezone)"
 // @Success 200 {object} SummaryResponse
+// @Failure 400 {object} gin.H{"error":string}
 // @Failure 401 {object} gin.H{"error":string}
 // @Router /reports/summary [get]
 func GetSummary(c *gin.Context) {
 	userID := c.GetUint("user_id")
-	var income, expense float64
-	config.DB.Table("transactions").Where("user_id = ? AND amount > 0", userID).Select("SUM(amount)").Scan(&income)
-	config.DB.Table("transactions").Where("user_id = ? AND amount < 0", userID).Select("SUM(amount)").Scan(&expense)
+	groupBy := c.Query("group_by")
+	if _, ok := defaultBuckets[groupBy]; groupBy != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be one of day, week, month, quarter, year"})
+		return
+	}
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var from, to *time.Time
+	if start := c.Query("start_date"); start != "" {
+		v, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD."})
+			return
+		}
+		from = &v
+	}
+	if end := c.Query("end_date"); end != "" {
+		v, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD."})
+			return
+		}
+		v = v.AddDate(0, 0, 1)
+		to = &v
+	}
+	if groupBy != "" {
+		if to == nil {
+			v := bucketNext(bucketStart(today(loc), groupBy), groupBy)
+			to = &v
+		}
+		if from == nil {
+			v := bucketStart(to.AddDate(0, 0, -1), groupBy)
+			for i := 1; i < defaultBuckets[groupBy]; i++ {
+				v = bucketStart(v.AddDate(0, 0, -1), groupBy)
+			}
+			from = &v
+		}
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must not be after end_date"})
+		return
+	}
+	account, err := accountFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Category breakdown
-	rows, err := config.DB.Raw(`SELECT c.name, SUM(t.amount) FROM transactions t JOIN categories c ON t.category_id = c.id WHERE t.user_id = ? GROUP BY c.name`, userID).Rows()
+	// whole months without an account filter are summed from the monthly rollups,
+	// anything finer from the transactions themselves
+	table, date, income, expense := "transactions t", "t.date", "CASE WHEN t.amount > 0 THEN t.amount ELSE 0 END", "CASE WHEN t.amount < 0 THEN t.amount ELSE 0 END"
+	rollups := useRollups(from, to, groupBy, account)
+	if rollups {
+		table, date, income, expense = "monthly_rollups t", "t.month", "t.income", "t.expense"
+	}
+	query := config.DB.Table(table).
+		Select(bucketSQL(date, groupBy)+" AS bucket, c.name, COALESCE(c.kind, ''), SUM("+income+"), SUM("+expense+")").
+		Joins("JOIN categories c ON t.category_id = c.id").
+		Where("t.user_id = ?", userID)
+	if !rollups {
+		query = query.Where("t.deleted_at IS NULL")
+		if account != "" {
+			query = query.Where("t.account_id = ?", account)
+		}
+	}
+	if from != nil {
+		query = query.Where(date+" >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where(date+" < ?", *to)
+	}
+	if cat := c.Query("category_id"); cat != "" {
+		id, _ := strconv.Atoi(cat)
+		query = query.Where("t.category_id IN ?", categoryGroupIDs(userID, uint(id)))
+	}
+	rows, err := query.Group("bucket, c.id").Rows()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	defer rows.Close()
-	byCat := make(map[string]float64)
+
+	resp := SummaryResponse{ByCategory: make(map[string]float64), GroupBy: groupBy, Timezone: loc.String()}
+	if from != nil {
+		resp.StartDate = from.Format("2006-01-02")
+	}
+	if to != nil {
+		resp.EndDate = to.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	buckets := make(map[time.Time]*SummaryBucket)
+	var order []time.Time
+	if groupBy != "" {
+		for start := bucketStart(*from, groupBy); start.Before(*to); start = bucketNext(start, groupBy) {
+			// the first and last buckets are clipped to the requested range
+			bucketFrom, bucketTo := start, bucketNext(start, groupBy)
+			if bucketFrom.Before(*from) {
+				bucketFrom = *from
+			}
+			if bucketTo.After(*to) {
+				bucketTo = *to
+			}
+			buckets[start] = &SummaryBucket{
+				Period:     bucketLabel(start, groupBy),
+				StartDate:  bucketFrom.Format("2006-01-02"),
+				EndDate:    bucketTo.AddDate(0, 0, -1).Format("2006-01-02"),
+				ByCategory: make(map[string]float64),
+			}
+			order = append(order, start)
+		}
+	}
 	for rows.Next() {
-		var name string
-		var sum float64
-		rows.Scan(&name, &sum)
-		byCat[name] = sum
-	}
-	c.JSON(http.StatusOK, SummaryResponse{
-		TotalIncome:  income,
-		TotalExpense: expense,
-		ByCategory:   byCat,
-	})
+		var start, name, kind string
+		var income, expense float64
+		if err := rows.Scan(&start, &name, &kind, &income, &expense); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		amount := income + expense
+		if kind == models.CategoryKindTransfer {
+			income, expense = 0, 0
+		}
+		resp.TotalIncome += income
+		resp.TotalExpense += expense
+		resp.ByCategory[name] += amount
+		day, _ := time.Parse("2006-01-02", start)
+		if bucket, ok := buckets[day]; ok {
+			bucket.Income += income
+			bucket.Expense += expense
+			bucket.Net += income + expense
+			bucket.ByCategory[name] += amount
+		}
+	}
+	resp.Net = resp.TotalIncome + resp.TotalExpense
+	for _, start := range order {
+		resp.Series = append(resp.Series, *buckets[start])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type BudgetStatus struct {
+	BudgetID     uint      `json:"budget_id"`
+	CategoryID   uint      `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Period       string    `json:"period"`
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	Budgeted     float64   `json:"budgeted"`
+	Rollover     float64   `json:"rollover"`
+	Spent        float64   `json:"spent"`
+	Remaining    float64   `json:"remaining"`
+	Percentage   float64   `json:"percentage"`
+}
+
+// parseDateRange reads the inclusive start_date/end_date query parameters and
+// returns them as a half-open [from, to) range. Missing values default to the current month.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	if start := c.Query("start_date"); start != "" {
+		v, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return from, to, errors.New("Invalid start_date format. Use YYYY-MM-DD.")
+		}
+		from = v
+	}
+	if end := c.Query("end_date"); end != "" {
+		v, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return from, to, errors.New("Invalid end_date format. Use YYYY-MM-DD.")
+		}
+		to = v.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("start_date must not be after end_date")
+	}
+	return from, to, nil
+}
+
+// spentBetween returns the net spending (expenses minus refunds) in the given categories over [from, to)
+func spentBetween(userID uint, categoryIDs []uint, from, to time.Time) float64 {
+	var sum float64
+	config.DB.Model(&models.Transaction{}).
+		Where("user_id = ? AND category_id IN ? AND date >= ? AND date < ?", userID, categoryIDs, from, to).
+		Select("COALESCE(SUM(amount), 0)").Scan(&sum)
+	return -sum
+}
+
+// budgetStatus computes a budget's figures over the budget periods overlapping [from, to).
+// With rollover enabled, the unused or overspent amount of every earlier period
+// since the budget started is carried into the first period of the range.
+// The second result is false when no budget period overlaps the range.
+func budgetStatus(b models.Budget, from, to time.Time) (BudgetStatus, bool) {
+	periods := b.Periods(from, to)
+	if len(periods) == 0 {
+		return BudgetStatus{}, false
+	}
+	ids := categoryGroupIDs(b.UserID, b.CategoryID)
+	start, end := periods[0][0], periods[len(periods)-1][1]
+	status := BudgetStatus{
+		BudgetID:   b.ID,
+		CategoryID: b.CategoryID,
+		Period:     b.Period,
+		StartDate:  start,
+		EndDate:    end.AddDate(0, 0, -1),
+		Budgeted:   b.Amount * float64(len(periods)),
+		Spent:      spentBetween(b.UserID, ids, start, end),
+	}
+	if b.Rollover && b.Period != models.BudgetPeriodCustom {
+		for _, p := range b.Periods(b.StartDate, start) {
+			status.Rollover += b.Amount - spentBetween(b.UserID, ids, p[0], p[1])
+		}
+	}
+	var cat models.Category
+	if config.DB.Unscoped().First(&cat, b.CategoryID).Error == nil {
+		status.CategoryName = cat.Name
+	}
+	available := status.Budgeted + status.Rollover
+	status.Remaining = available - status.Spent
+	if available > 0 {
+		status.Percentage = status.Spent / available * 100
+	}
+	return status, true
+}
+
+// GetBudgetReport returns actual-vs-budget figures for the authenticated user
+// @Summary Get budget report
+// @Description Returns budgeted, spent, remaining and percentage for every budget over the budget periods overlapping the date range (default: current month)
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {array} BudgetStatus
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/budget [get]
+func GetBudgetReport(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var budgets []models.Budget
+	if err := config.DB.Where("user_id = ?", userID).Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report := []BudgetStatus{}
+	for _, b := range budgets {
+		if status, ok := budgetStatus(b, from, to); ok {
+			report = append(report, status)
+		}
+	}
+	c.JSON(http.StatusOK, report)
 }
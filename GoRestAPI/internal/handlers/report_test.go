@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/rollup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryBuckets(t *testing.T) {
+	d := time.Date(2025, 8, 14, 0, 0, 0, 0, time.UTC) // a Thursday
+	cases := []struct {
+		groupBy, start, next, label string
+	}{
+		{"day", "2025-08-14", "2025-08-15", "2025-08-14"},
+		{"week", "2025-08-11", "2025-08-18", "2025-W33"},
+		{"month", "2025-08-01", "2025-09-01", "2025-08"},
+		{"quarter", "2025-07-01", "2025-10-01", "2025-Q3"},
+		{"year", "2025-01-01", "2026-01-01", "2025"},
+	}
+	for _, tc := range cases {
+		start := bucketStart(d, tc.groupBy)
+		assert.Equal(t, tc.start, start.Format("2006-01-02"), tc.groupBy)
+		assert.Equal(t, tc.next, bucketNext(start, tc.groupBy).Format("2006-01-02"), tc.groupBy)
+		assert.Equal(t, tc.label, bucketLabel(start, tc.groupBy), tc.groupBy)
+	}
+}
+
+func TestUseRollups(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	apr := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	mid := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	assert.True(t, useRollups(nil, nil, "", ""))
+	assert.True(t, useRollups(&jan, &apr, "month", ""))
+	assert.True(t, useRollups(&jan, &apr, "quarter", ""))
+	assert.False(t, useRollups(&jan, &mid, "", ""))
+	assert.False(t, useRollups(&jan, &apr, "week", ""))
+	assert.False(t, useRollups(&jan, &apr, "", "3"))
+}
+
+func TestBucketSQL(t *testing.T) {
+	setupTestDB(t)
+	for d := time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC); d.Before(time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)); d = d.AddDate(0, 0, 1) {
+		for groupBy := range defaultBuckets {
+			var got string
+			config.DB.Raw("SELECT "+bucketSQL("d", groupBy)+" FROM (SELECT ? AS d)", d).Scan(&got)
+			assert.Equal(t, bucketStart(d, groupBy).Format("2006-01-02"), got, "%s %s", groupBy, d.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestGetSummary(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "summary@example.com")
+	salary := models.Category{UserID: user.ID, Name: "Salary", Kind: models.CategoryKindIncome}
+	food := models.Category{UserID: user.ID, Name: "Food", Kind: models.CategoryKindExpense}
+	config.DB.Create(&salary)
+	config.DB.Create(&food)
+	card := models.Account{UserID: user.ID, Name: "Card", Type: "credit"}
+	config.DB.Create(&card)
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	for _, tx := range []models.Transaction{
+		{CategoryID: salary.ID, Amount: 2000, Date: day("2025-01-31")},
+		{CategoryID: food.ID, Amount: -50, Date: day("2025-02-03"), AccountID: &card.ID},
+		{CategoryID: food.ID, Amount: -30, Date: day("2025-02-04"), AccountID: &card.ID},
+		{CategoryID: food.ID, Amount: 10, Date: day("2025-04-02")},
+	} {
+		tx.UserID = user.ID
+		config.DB.Create(&tx)
+	}
+	assert.NoError(t, rollup.Rebuild(config.DB, user.ID))
+	summary := func(query string) SummaryResponse {
+		w := serve(user.ID, GetSummary, http.MethodGet, "/reports/summary", "/reports/summary?"+query, "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp SummaryResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	// from the rollups
+	resp := summary("start_date=2025-01-01&end_date=2025-06-30&group_by=quarter")
+	assert.Equal(t, 2010.0, resp.TotalIncome)
+	assert.Equal(t, -80.0, resp.TotalExpense)
+	assert.Equal(t, map[string]float64{"Salary": 2000, "Food": -70}, resp.ByCategory)
+	assert.Len(t, resp.Series, 2)
+	assert.Equal(t, SummaryBucket{Period: "2025-Q1", StartDate: "2025-01-01", EndDate: "2025-03-31", Income: 2000, Expense: -80, Net: 1920,
+		ByCategory: map[string]float64{"Salary": 2000, "Food": -80}}, resp.Series[0])
+
+	// from the transactions, by account name
+	resp = summary("start_date=2025-01-27&end_date=2025-02-09&group_by=week&account=card")
+	assert.Equal(t, -80.0, resp.Net)
+	assert.Len(t, resp.Series, 2)
+	assert.Zero(t, resp.Series[0].Net)
+	assert.Equal(t, "2025-W06", resp.Series[1].Period)
+	assert.Equal(t, map[string]float64{"Food": -80}, resp.Series[1].ByCategory)
+	assert.Equal(t, resp, summary("start_date=2025-01-27&end_date=2025-02-09&group_by=week&account_id="+strconv.Itoa(int(card.ID))))
+
+	w := serve(user.ID, GetSummary, http.MethodGet, "/reports/summary", "/reports/summary?account=savings", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+	"expense-tracker/internal/document"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// categorySection lists the category totals accepted by keep, largest first
+func categorySection(heading, empty string, totals map[uint]categoryTotal, keep func(categoryTotal) bool) document.Section {
+	var list []categoryTotal
+	var sum float64
+	for _, t := range totals {
+		if keep(t) {
+			list = append(list, t)
+			sum += t.Amount
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if a, b := math.Abs(list[i].Amount), math.Abs(list[j].Amount); a != b {
+			return a > b
+		}
+		return list[i].Name < list[j].Name
+	})
+	table := document.Table{
+		Columns: []document.Column{{Title: "Category", Width: 3}, {Title: "Amount", Width: 1, Right: true}},
+		Total:   []string{"Total", money(sum)},
+	}
+	for _, t := range list {
+		table.Rows = append(table.Rows, []string{t.Name, money(t.Amount)})
+	}
+	return document.Section{Heading: heading, Table: table, Empty: empty}
+}
+
+// budgetSection lists the status of the user's budgets with a period overlapping [from, to)
+func budgetSection(userID uint, from, to time.Time, empty string) document.Section {
+	budgets := document.Table{Columns: []document.Column{
+		{Title: "Category", Width: 3}, {Title: "Period", Width: 2}, {Title: "Budgeted", Width: 1.3, Right: true},
+		{Title: "Spent", Width: 1.3, Right: true}, {Title: "Remaining", Width: 1.3, Right: true}, {Title: "Used", Width: 1, Right: true},
+	}}
+	var list []models.Budget
+	config.DB.Where("user_id = ?", userID).Find(&list)
+	for _, b := range list {
+		status, ok := budgetStatus(b, from, to)
+		if !ok {
+			continue
+		}
+		budgets.Rows = append(budgets.Rows, []string{
+			status.CategoryName,
+			status.StartDate.Format("2006-01-02") + " - " + status.EndDate.Format("01-02"),
+			money(status.Budgeted + status.Rollover),
+			money(status.Spent),
+			money(status.Remaining),
+			fmt.Sprintf("%.0f%%", status.Percentage),
+		})
+	}
+	return document.Section{Heading: "Budgets", Table: budgets, Empty: empty}
+}
+
+// statementDocument builds the statement of a user for the month starting at from:
+// account balances, income and expenses by category, budget status and every transaction.
+func statementDocument(user models.User, from time.Time) (document.Document, error) {
+	to := from.AddDate(0, 1, 0)
+	doc := document.Document{
+		Title:    "Statement " + from.Format("January 2006"),
+		Subtitle: fmt.Sprintf("%s - %s to %s", user.Email, from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02")),
+	}
+
+	// balances at the start of the first day and the end of the last day
+	balances := document.Table{Columns: []document.Column{
+		{Title: "Account", Width: 3}, {Title: "Opening", Width: 1, Right: true}, {Title: "Closing", Width: 1, Right: true}, {Title: "Change", Width: 1, Right: true},
+	}}
+	var accounts []models.Account
+	if err := config.DB.Where("user_id = ?", user.ID).Order("name").Find(&accounts).Error; err != nil {
+		return doc, err
+	}
+	var openingTotal, closingTotal float64
+	addBalance := func(name string, opening, closing float64) {
+		balances.Rows = append(balances.Rows, []string{name, money(opening), money(closing), money(closing - opening)})
+		openingTotal += opening
+		closingTotal += closing
+	}
+	for _, acc := range accounts {
+		addBalance(acc.Name, accountBalance(acc, from), accountBalance(acc, to))
+	}
+	var unassigned int64
+	config.DB.Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NULL AND date < ?", user.ID, to).Count(&unassigned)
+	if unassigned > 0 {
+		balanceBefore := func(before time.Time) float64 {
+			var sum float64
+			config.DB.Model(&models.Transaction{}).Where("user_id = ? AND account_id IS NULL AND date < ?", user.ID, before).
+				Select("COALESCE(SUM(amount), 0)").Scan(&sum)
+			return sum
+		}
+		addBalance("No account", balanceBefore(from), balanceBefore(to))
+	}
+	balances.Total = []string{"Total", money(openingTotal), money(closingTotal), money(closingTotal - openingTotal)}
+	doc.Sections = append(doc.Sections, document.Section{Heading: "Balances", Table: balances, Empty: "No transactions or accounts."})
+
+	totals, err := categoryTotals(user.ID, from, to, "")
+	if err != nil {
+		return doc, err
+	}
+	doc.Sections = append(doc.Sections,
+		categorySection("Income by category", "No income this month.", totals, func(t categoryTotal) bool {
+			return t.Kind == models.CategoryKindIncome
+		}),
+		categorySection("Expenses by category", "No expenses this month.", totals, func(t categoryTotal) bool {
+			return t.Kind != models.CategoryKindIncome && t.Kind != models.CategoryKindTransfer
+		}),
+	)
+
+	doc.Sections = append(doc.Sections, budgetSection(user.ID, from, to, "No budgets for this month."))
+
+	rows, err := config.DB.Table("transactions t").
+		Select("t.date, c.name, COALESCE(t.payee, ''), COALESCE(t.description, ''), COALESCE(a.name, ''), t.amount").
+		Joins("JOIN categories c ON t.category_id = c.id").
+		Joins("LEFT JOIN accounts a ON t.account_id = a.id").
+		Where("t.user_id = ? AND t.deleted_at IS NULL AND t.date >= ? AND t.date < ?", user.ID, from, to).
+		Order("t.date, t.id").Rows()
+	if err != nil {
+		return doc, err
+	}
+	defer rows.Close()
+	txs := document.Table{Columns: []document.Column{
+		{Title: "Date", Width: 1.4}, {Title: "Category", Width: 2}, {Title: "Payee", Width: 2}, {Title: "Description", Width: 3},
+		{Title: "Account", Width: 1.6}, {Title: "Amount", Width: 1.4, Right: true},
+	}}
+	var net float64
+	for rows.Next() {
+		var date time.Time
+		var category, payee, description, account string
+		var amount float64
+		if err := rows.Scan(&date, &category, &payee, &description, &account, &amount); err != nil {
+			return doc, err
+		}
+		txs.Rows = append(txs.Rows, []string{date.Format("2006-01-02"), category, payee, description, account, money(amount)})
+		net += amount
+	}
+	txs.Total = []string{"Net", "", "", "", "", money(net)}
+	doc.Sections = append(doc.Sections, document.Section{Heading: "Transactions", Table: txs, Empty: "No transactions this month."})
+	return doc, rows.Err()
+}
+
+// GetStatement renders the monthly statement of the authenticated user
+// @Summary Monthly statement
+// @Description Renders a monthly statement with opening and closing balances per account, income and expense totals by category (transfers excluded), budget status and the full transaction listing, as a paginated PDF or as HTML from the same layout
+// @Tags reports
+// @Security BearerAuth
+// @Produce application/pdf
+// @Produce text/html
+// @Param month query string false "Month (YYYY-MM, default: current month)"
+// @Param format query string false "pdf (default) or html"
+// @Success 200 {file} file
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/statement [get]
+func GetStatement(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if m := c.Query("month"); m != "" {
+		v, err := parseMonth(m)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from = v
+	}
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf or html"})
+		return
+	}
+	doc, err := statementDocument(user, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var buf bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = doc.WritePDF(&buf)
+	} else {
+		err = doc.WriteHTML(&buf)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="statement-%s.%s"`, from.Format("2006-01"), format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// subscriptionHistoryDays is how far back charges are scanned
+const subscriptionHistoryDays = 730
+
+// cadence is a recurring interval recognised by the subscription detector
+type cadence struct {
+	Frequency  string
+	Days       float64
+	Tolerance  float64
+	PerYear    float64
+	MinCharges int
+}
+
+var cadences = []cadence{
+	{models.FrequencyWeekly, 7, 1, 52, 3},
+	{models.FrequencyBiweekly, 14, 2, 26, 3},
+	{models.FrequencyMonthly, 30.4, 4, 12, 3},
+	{models.FrequencyQuarterly, 91.3, 7, 4, 3},
+	{models.FrequencyYearly, 365.25, 10, 1, 2},
+}
+
+type Subscription struct {
+	Payee          string  `json:"payee"`
+	CategoryID     uint    `json:"category_id"`
+	CategoryName   string  `json:"category_name"`
+	AccountID      *uint   `json:"account_id"`
+	Frequency      string  `json:"frequency"`
+	Amount         float64 `json:"amount"`
+	Charges        int     `json:"charges"`
+	FirstCharge    string  `json:"first_charge"`
+	LastCharge     string  `json:"last_charge"`
+	NextExpected   string  `json:"next_expected"`
+	AnnualizedCost float64 `json:"annualized_cost"`
+	Active         bool    `json:"active"`
+	RecurringID    *uint   `json:"recurring_id"`
+}
+
+type ConvertSubscriptionInput struct {
+	Payee string `json:"payee" binding:"required"`
+}
+
+// subscriptionCharge is an expense as seen by the subscription detector
+type subscriptionCharge struct {
+	Date         time.Time
+	Amount       float64
+	Payee        string
+	CategoryID   uint
+	CategoryName string
+	AccountID    *uint
+}
+
+// fractionWithin returns the share of values within tolerance of target
+func fractionWithin(values []float64, target, tolerance float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	n := 0
+	for _, v := range values {
+		if math.Abs(v-target) <= tolerance {
+			n++
+		}
+	}
+	return float64(n) / float64(len(values))
+}
+
+// detectSubscriptions finds payees charged at a regular cadence for a similar
+// amount. Charges must be sorted by date. A subscription is active when its
+// next expected charge is not overdue as of today.
+func detectSubscriptions(charges []subscriptionCharge, today time.Time) []Subscription {
+	byPayee := make(map[string][]subscriptionCharge)
+	var payees []string
+	for _, ch := range charges {
+		key := payeeKey(ch.Payee, "")
+		if key == "" {
+			continue
+		}
+		if _, ok := byPayee[key]; !ok {
+			payees = append(payees, key)
+		}
+		byPayee[key] = append(byPayee[key], ch)
+	}
+
+	subs := []Subscription{}
+	for _, key := range payees {
+		list := byPayee[key]
+		if len(list) < 2 {
+			continue
+		}
+		intervals := make([]float64, 0, len(list)-1)
+		amounts := make([]float64, 0, len(list))
+		for i, ch := range list {
+			amounts = append(amounts, ch.Amount)
+			if i > 0 {
+				intervals = append(intervals, ch.Date.Sub(list[i-1].Date).Hours()/24)
+			}
+		}
+		interval := median(intervals)
+		amount := median(amounts)
+		for _, cad := range cadences {
+			if len(list) < cad.MinCharges || math.Abs(interval-cad.Days) > cad.Tolerance {
+				continue
+			}
+			// most intervals must match the cadence and most amounts the usual amount
+			if fractionWithin(intervals, cad.Days, cad.Tolerance) < 0.75 || fractionWithin(amounts, amount, 0.2*math.Abs(amount)) < 0.75 {
+				break
+			}
+			last := list[len(list)-1]
+			schedule := models.RecurringTransaction{Frequency: cad.Frequency, StartDate: last.Date}
+			next := schedule.Occurrences(last.Date.AddDate(0, 0, 1), last.Date.AddDate(2, 0, 0))[0]
+			subs = append(subs, Subscription{
+				Payee:          last.Payee,
+				CategoryID:     last.CategoryID,
+				CategoryName:   last.CategoryName,
+				AccountID:      last.AccountID,
+				Frequency:      cad.Frequency,
+				Amount:         last.Amount,
+				Charges:        len(list),
+				FirstCharge:    list[0].Date.Format("2006-01-02"),
+				LastCharge:     last.Date.Format("2006-01-02"),
+				NextExpected:   next.Format("2006-01-02"),
+				AnnualizedCost: -last.Amount * cad.PerYear,
+				Active:         !next.AddDate(0, 0, int(cad.Tolerance)).Before(today),
+			})
+			break
+		}
+	}
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].AnnualizedCost > subs[j].AnnualizedCost })
+	return subs
+}
+
+// userSubscriptions detects the subscriptions of a user and links them to
+// the recurring transactions they were converted into.
+func userSubscriptions(userID uint, today time.Time) ([]Subscription, error) {
+	rows, err := config.DB.Table("transactions t").
+		Select("t.date, t.amount, COALESCE(NULLIF(t.payee, ''), t.description, ''), t.category_id, c.name, t.account_id").
+		Joins("JOIN categories c ON t.category_id = c.id").
+		Where("t.user_id = ? AND t.deleted_at IS NULL AND t.amount < 0 AND COALESCE(c.kind, '') <> ?", userID, models.CategoryKindTransfer).
+		Where("t.date >= ? AND t.date <= ?", today.AddDate(0, 0, -subscriptionHistoryDays), today).
+		Order("t.date").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var charges []subscriptionCharge
+	for rows.Next() {
+		var ch subscriptionCharge
+		if err := rows.Scan(&ch.Date, &ch.Amount, &ch.Payee, &ch.CategoryID, &ch.CategoryName, &ch.AccountID); err != nil {
+			return nil, err
+		}
+		ch.Date = ch.Date.UTC()
+		ch.Payee = strings.TrimSpace(ch.Payee)
+		charges = append(charges, ch)
+	}
+	subs := detectSubscriptions(charges, today)
+
+	var recurring []models.RecurringTransaction
+	config.DB.Where("user_id = ? AND payee <> ''", userID).Find(&recurring)
+	for i := range subs {
+		for _, r := range recurring {
+			if payeeKey(r.Payee, "") == payeeKey(subs[i].Payee, "") {
+				id := r.ID
+				subs[i].RecurringID = &id
+			}
+		}
+	}
+	return subs, nil
+}
+
+// GetSubscriptionReport returns the subscriptions detected in the authenticated user's transactions
+// @Summary Detected subscriptions
+// @Description Scans the last two years of expenses for payees charged at a regular cadence (weekly, biweekly, monthly, quarterly or yearly) for a similar amount. Payees fall back to the description when unset. Each subscription has its last charge, next expected charge and annualized cost; it is inactive when the next charge is overdue.
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param tz query string false "IANA timezone (default: the user's timezone)"
+// @Success 200 {array} Subscription
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /reports/subscriptions [get]
+func GetSubscriptionReport(c *gin.Context) {
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	subs, err := userSubscriptions(c.GetUint("user_id"), today(loc))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// ConvertSubscription creates a recurring transaction from a detected subscription
+// @Summary Convert subscription to recurring transaction
+// @Description Creates a recurring transaction with the payee, category, account, amount and cadence of a detected subscription, scheduled from its last charge
+// @Tags recurring
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body ConvertSubscriptionInput true "Payee of the detected subscription"
+// @Success 201 {object} models.RecurringTransaction
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 409 {object} gin.H{"error":string}
+// @Router /subscriptions/convert [post]
+func ConvertSubscription(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input ConvertSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	subs, err := userSubscriptions(userID, today(loc))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, sub := range subs {
+		if payeeKey(sub.Payee, "") != payeeKey(input.Payee, "") {
+			continue
+		}
+		if sub.RecurringID != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Subscription is already a recurring transaction"})
+			return
+		}
+		start, _ := time.Parse("2006-01-02", sub.LastCharge)
+		r := models.RecurringTransaction{
+			UserID:      userID,
+			CategoryID:  sub.CategoryID,
+			AccountID:   sub.AccountID,
+			Amount:      sub.Amount,
+			Payee:       sub.Payee,
+			Description: sub.Payee,
+			Frequency:   sub.Frequency,
+			StartDate:   start,
+			Active:      true,
+		}
+		if err := config.DB.Create(&r).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, r)
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+}
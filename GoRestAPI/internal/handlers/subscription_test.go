@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectSubscriptions(t *testing.T) {
+	d := func(s string) time.Time {
+		v, _ := time.Parse("2006-01-02", s)
+		return v
+	}
+	charges := []subscriptionCharge{
+		{Date: d("2025-01-31"), Amount: -15.99, Payee: "StreamFlix", CategoryID: 9},
+		{Date: d("2025-02-03"), Amount: -60, Payee: "Market", CategoryID: 5},
+		{Date: d("2025-02-28"), Amount: -15.99, Payee: "StreamFlix", CategoryID: 9},
+		{Date: d("2025-03-01"), Amount: -99, Payee: "Cloud Backup", CategoryID: 9},
+		{Date: d("2025-03-04"), Amount: -12, Payee: "Market", CategoryID: 5},
+		{Date: d("2025-03-31"), Amount: -17.99, Payee: "streamflix", CategoryID: 9},
+		{Date: d("2025-04-30"), Amount: -17.99, Payee: "StreamFlix", CategoryID: 9},
+		{Date: d("2025-05-20"), Amount: -140, Payee: "Market", CategoryID: 5},
+		{Date: d("2026-03-02"), Amount: -99, Payee: "Cloud Backup", CategoryID: 9},
+	}
+	subs := detectSubscriptions(charges, d("2026-05-10"))
+	assert.Len(t, subs, 2)
+
+	assert.Equal(t, "monthly", subs[0].Frequency)
+	assert.Equal(t, 4, subs[0].Charges)
+	assert.Equal(t, -17.99, subs[0].Amount)
+	assert.Equal(t, "2025-05-30", subs[0].NextExpected)
+	assert.InDelta(t, 215.88, subs[0].AnnualizedCost, 1e-9)
+	assert.False(t, subs[0].Active)
+
+	assert.Equal(t, "Cloud Backup", subs[1].Payee)
+	assert.Equal(t, "yearly", subs[1].Frequency)
+	assert.Equal(t, "2027-03-02", subs[1].NextExpected)
+	assert.Equal(t, 99.0, subs[1].AnnualizedCost)
+	assert.True(t, subs[1].Active)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxTagLength is the longest tag name accepted
+const maxTagLength = 50
+
+type TagCount struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Transactions int    `json:"transactions"`
+}
+
+// normalizeTags trims and lowercases tag names and drops duplicates
+func normalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, errors.New("Tags must not be empty")
+		}
+		if len(name) > maxTagLength {
+			return nil, errors.New("Tags must be at most 50 characters")
+		}
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// setTransactionTags replaces the tags of a transaction, creating missing tags
+func setTransactionTags(db *gorm.DB, userID, transactionID uint, names []string) error {
+	if err := db.Where("transaction_id = ?", transactionID).Delete(&models.TransactionTag{}).Error; err != nil {
+		return err
+	}
+	for _, name := range names {
+		tag := models.Tag{UserID: userID, Name: name}
+		if err := db.Where(tag).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		if err := db.Create(&models.TransactionTag{TransactionID: transactionID, TagID: tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTags fills in the tags of the transactions
+func loadTags(db *gorm.DB, txs []models.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(txs))
+	index := make(map[uint]int, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID
+		index[tx.ID] = i
+		txs[i].Tags = []string{}
+	}
+	rows, err := db.Table("transaction_tags tt").
+		Select("tt.transaction_id, tg.name").
+		Joins("JOIN tags tg ON tg.id = tt.tag_id").
+		Where("tt.transaction_id IN ?", ids).
+		Order("tg.name").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		txs[index[id]].Tags = append(txs[index[id]].Tags, name)
+	}
+	return rows.Err()
+}
+
+// ListTags returns the tags of the authenticated user
+// @Summary List tags
+// @Description Get the tags of the current user with the number of transactions carrying each
+// @Tags tags
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} TagCount
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /tags [get]
+func ListTags(c *gin.Context) {
+	list := []TagCount{}
+	err := config.DB.Table("tags tg").
+		Select("tg.id, tg.name, COUNT(t.id) AS transactions").
+		Joins("LEFT JOIN transaction_tags tt ON tt.tag_id = tg.id").
+		Joins("LEFT JOIN transactions t ON t.id = tt.transaction_id AND t.deleted_at IS NULL").
+		Where("tg.user_id = ?", c.GetUint("user_id")).
+		Group("tg.id, tg.name").Order("tg.name").Scan(&list).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// DeleteTag deletes a tag of the authenticated user
+// @Summary Delete tag
+// @Description Delete a tag and remove it from every transaction; the transactions are kept
+// @Tags tags
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /tags/{id} [delete]
+func DeleteTag(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var tag models.Tag
+	if err := config.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		var ids []uint
+		if err := db.Model(&models.TransactionTag{}).Where("tag_id = ?", tag.ID).Pluck("transaction_id", &ids).Error; err != nil {
+			return err
+		}
+		if err := db.Where("tag_id = ?", tag.ID).Delete(&models.TransactionTag{}).Error; err != nil {
+			return err
+		}
+		if err := db.Delete(&tag).Error; err != nil {
+			return err
+		}
+		return search.Index(db, ids...)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/templates"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ApplyTemplateInput struct {
+	Locale string `json:"locale"`
+}
+
+// ListCategoryTemplates returns the category templates a user can pick from
+// @Summary List category templates
+// @Description Get the built-in and imported category templates, optionally for a single locale
+// @Tags categories
+// @Security BearerAuth
+// @Produce json
+// @Param locale query string false "Locale (e.g. en, es, fr)"
+// @Success 200 {array} templates.Template
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /category-templates [get]
+func ListCategoryTemplates(c *gin.Context) {
+	list, err := templates.List(config.DB, c.Query("locale"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// ApplyCategoryTemplate adds the categories of a template to the authenticated user
+// @Summary Apply category template
+// @Description Create the categories of a template for the current user, skipping names that already exist
+// @Tags categories
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param key path string true "Template key"
+// @Param input body ApplyTemplateInput false "Template locale"
+// @Success 201 {array} models.Category
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /category-templates/{key}/apply [post]
+func ApplyCategoryTemplate(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input ApplyTemplateInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tmpl, err := templates.Find(config.DB, c.Param("key"), input.Locale)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	var created []models.Category
+	err = config.DB.Transaction(func(db *gorm.DB) error {
+		created, err = applyTemplate(db, actorOf(c), userID, tmpl)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// applyTemplate creates the categories of a template for a user and records
+// an audit entry for each of them
+func applyTemplate(db *gorm.DB, actor audit.Actor, userID uint, tmpl templates.Template) ([]models.Category, error) {
+	created, err := templates.Apply(db, userID, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	for _, cat := range created {
+		if err := audit.Record(db, actor, audit.ActionCreate, audit.EntityCategory, cat.ID, cat.Version, nil, cat); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// ImportCategoryTemplate stores a category template (admin only)
+// @Summary Import category template
+// @Description Create or replace a category template. Imported templates override built-in ones with the same key and locale.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body templates.Template true "Template"
+// @Success 201 {object} templates.Template
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 403 {object} gin.H{"error":string}
+// @Router /admin/category-templates [post]
+func ImportCategoryTemplate(c *gin.Context) {
+	var tmpl templates.Template
+	if err := c.ShouldBindJSON(&tmpl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tmpl.Builtin = false
+	if err := tmpl.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := templates.Save(config.DB, tmpl); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// ExportCategoryTemplate returns a category template as a downloadable JSON file (admin only)
+// @Summary Export category template
+// @Description Download a category template in the format accepted by the import endpoint
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param key path string true "Template key"
+// @Param locale query string false "Locale (default: en)"
+// @Success 200 {object} templates.Template
+// @Failure 403 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /admin/category-templates/{key} [get]
+func ExportCategoryTemplate(c *gin.Context) {
+	tmpl, err := templates.Find(config.DB, c.Param("key"), c.Query("locale"))
+	if errors.Is(err, templates.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\""+tmpl.Key+"."+tmpl.Locale+".json\"")
+	c.JSON(http.StatusOK, tmpl)
+}
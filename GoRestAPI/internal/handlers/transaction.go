@@ -1,19 +1,198 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	"expense-tracker/internal/models"
 	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/filter"
+	"expense-tracker/internal/rollup"
+	"expense-tracker/internal/search"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TransactionInput struct {
 	Amount      float64   `json:"amount" binding:"required"`
 	Date        string    `json:"date" binding:"required"`
 	CategoryID  uint      `json:"category_id" binding:"required"`
+	AccountID   *uint     `json:"account_id"`
+	Payee       string    `json:"payee"`
 	Description string    `json:"description"`
+	Notes       string    `json:"notes"`
+	// Tags replace the transaction's tags; omit them to keep the current ones
+	Tags []string `json:"tags"`
+}
+
+// validateCategory checks that the category belongs to the user and that the
+// amount sign matches the category kind. Archived categories only accept
+// transactions that were already filed under them.
+func validateCategory(userID, categoryID uint, amount float64, allowArchived bool) error {
+	var cat models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", categoryID, userID).First(&cat).Error; err != nil {
+		return errors.New("Category not found")
+	}
+	if cat.Archived && !allowArchived {
+		return errors.New("Category is archived")
+	}
+	if !cat.AllowsAmount(amount) {
+		return errors.New("Amount sign does not match category kind " + cat.Kind)
+	}
+	return nil
+}
+
+// accountFilter returns the ID of the account a request is restricted to, given
+// by the account parameter as an ID or a case-insensitive name, or by
+// account_id. It returns "" when no account is given.
+func accountFilter(c *gin.Context) (string, error) {
+	value := c.Query("account")
+	if value == "" {
+		return c.Query("account_id"), nil
+	}
+	if _, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return value, nil
+	}
+	var account models.Account
+	if err := config.DB.Where("user_id = ? AND LOWER(name) = LOWER(?)", c.GetUint("user_id"), value).First(&account).Error; err != nil {
+		return "", errors.New("Account not found")
+	}
+	return strconv.FormatUint(uint64(account.ID), 10), nil
+}
+
+// validateAccount checks that an optional account belongs to the user
+func validateAccount(userID uint, accountID *uint) error {
+	if accountID == nil {
+		return nil
+	}
+	var count int64
+	config.DB.Model(&models.Account{}).Where("id = ? AND user_id = ?", *accountID, userID).Count(&count)
+	if count == 0 {
+		return errors.New("Account not found")
+	}
+	return nil
+}
+
+// applyInput validates input and applies it to tx, a new transaction or the
+// one being updated. Tags are only replaced when the input has them; archived
+// categories are accepted when the transaction already belongs to them.
+func applyInput(tx *models.Transaction, input TransactionInput) error {
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		return errors.New("Invalid date format. Use YYYY-MM-DD.")
+	}
+	if err := validateCategory(tx.UserID, input.CategoryID, input.Amount, tx.ID != 0 && input.CategoryID == tx.CategoryID); err != nil {
+		return err
+	}
+	if err := validateAccount(tx.UserID, input.AccountID); err != nil {
+		return err
+	}
+	if input.Tags != nil || tx.ID == 0 {
+		tags, err := normalizeTags(input.Tags)
+		if err != nil {
+			return err
+		}
+		tx.Tags = tags
+	}
+	tx.Amount = input.Amount
+	tx.Date = date
+	tx.CategoryID = input.CategoryID
+	tx.AccountID = input.AccountID
+	tx.Payee = input.Payee
+	tx.Description = input.Description
+	tx.Notes = input.Notes
+	return nil
+}
+
+// saveTransaction creates or updates a transaction together with its monthly
+// rollup, its tags (when tx.Tags is set), its search index entry and an audit
+// entry for action. before is the stored version of an updated transaction
+// and nil for a new one; an update fails with errVersionConflict when the row
+// no longer has that version.
+func saveTransaction(db *gorm.DB, actor audit.Actor, action string, tx *models.Transaction, before *models.Transaction) error {
+	var err error
+	if before == nil {
+		tx.Version = 1
+		if err := db.Create(tx).Error; err != nil {
+			return err
+		}
+		err = rollup.Add(db, *tx)
+	} else {
+		tx.Version = before.Version + 1
+		result := db.Model(tx).Where("version = ?", before.Version).Select("*").Updates(tx)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		err = rollup.Move(db, *before, *tx)
+	}
+	if err != nil {
+		return err
+	}
+	// the stored tags of before are read before they are replaced
+	var old *models.Transaction
+	if before != nil {
+		if old, err = withTags(db, *before); err != nil {
+			return err
+		}
+	}
+	if tx.Tags != nil {
+		if err := setTransactionTags(db, tx.UserID, tx.ID, tx.Tags); err != nil {
+			return err
+		}
+	}
+	if err := search.Index(db, tx.ID); err != nil {
+		return err
+	}
+	return recordTransaction(db, actor, action, old, tx)
+}
+
+// deleteTransaction moves a transaction to the trash and takes it out of the
+// monthly rollups and the search index. Its tags are kept for a restore.
+func deleteTransaction(db *gorm.DB, actor audit.Actor, tx models.Transaction) error {
+	old, err := withTags(db, tx)
+	if err != nil {
+		return err
+	}
+	if err := db.Delete(&tx).Error; err != nil {
+		return err
+	}
+	if err := rollup.Remove(db, tx); err != nil {
+		return err
+	}
+	if err := search.Remove(db, tx.ID); err != nil {
+		return err
+	}
+	return audit.Record(db, actor, audit.ActionDelete, audit.EntityTransaction, tx.ID, tx.Version, old, nil)
+}
+
+// withTags returns a copy of a transaction with its stored tags
+func withTags(db *gorm.DB, tx models.Transaction) (*models.Transaction, error) {
+	txs := []models.Transaction{tx}
+	if err := loadTags(db, txs); err != nil {
+		return nil, err
+	}
+	return &txs[0], nil
+}
+
+// recordTransaction appends an audit entry for a written transaction. before
+// must carry its tags; the tags of tx are read from the database.
+func recordTransaction(db *gorm.DB, actor audit.Actor, action string, before, tx *models.Transaction) error {
+	after, err := withTags(db, *tx)
+	if err != nil {
+		return err
+	}
+	return audit.Record(db, actor, action, audit.EntityTransaction, tx.ID, tx.Version, before, after)
+}
+
+// actorOf returns the user making a request and the IP it came from
+func actorOf(c *gin.Context) audit.Actor {
+	return audit.Actor{UserID: c.GetUint("user_id"), IP: c.ClientIP()}
 }
 
 // CreateTransaction creates a new transaction for the authenticated user
@@ -35,22 +214,17 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 	userID := c.GetUint("user_id")
-	parsedDate, err := time.Parse("2006-01-02", input.Date)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD."})
+	tx := models.Transaction{UserID: userID}
+	if err := applyInput(&tx, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	tx := models.Transaction{
-		Amount:      input.Amount,
-		Date:        parsedDate,
-		CategoryID:  input.CategoryID,
-		UserID:      userID,
-		Description: input.Description,
-	}
-	if err := config.DB.Create(&tx).Error; err != nil {
+	if err := config.DB.Transaction(func(db *gorm.DB) error { return saveTransaction(db, actorOf(c), audit.ActionCreate, &tx, nil) }); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	checkBudgetAlerts(userID, tx.CategoryID, tx.Date)
+	c.Header("ETag", etag(tx.Version))
 	c.JSON(http.StatusCreated, tx)
 }
 
@@ -73,7 +247,10 @@ func GetTransaction(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
-	c.JSON(http.StatusOK, tx)
+	txs := []models.Transaction{tx}
+	loadTags(config.DB, txs)
+	c.Header("ETag", etag(tx.Version))
+	c.JSON(http.StatusOK, txs[0])
 }
 
 // UpdateTransaction updates a transaction for the authenticated user
@@ -84,11 +261,13 @@ func GetTransaction(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Transaction ID"
+// @Param If-Match header string false "ETag of the version being replaced"
 // @Param input body TransactionInput true "Transaction info"
 // @Success 200 {object} models.Transaction
 // @Failure 400 {object} gin.H{"error":string}
 // @Failure 401 {object} gin.H{"error":string}
 // @Failure 404 {object} gin.H{"error":string}
+// @Failure 412 {object} gin.H{"error":string}
 // @Router /transactions/{id} [put]
 func UpdateTransaction(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
@@ -98,27 +277,103 @@ func UpdateTransaction(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
+	if !ifMatch(c, tx.Version) {
+		return
+	}
 	var input TransactionInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	parsedDate, err := time.Parse("2006-01-02", input.Date)
+	updateTransaction(c, audit.ActionUpdate, tx, input)
+}
+
+// PatchTransaction partially updates a transaction for the authenticated user
+// @Summary Patch transaction
+// @Description Update some fields of a transaction with a JSON Merge Patch (RFC 7386): members present in the body replace the stored values, null clears account_id, payee, description, notes or tags, and omitted members are kept.
+// @Tags transactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Param input body object true "Merge patch"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 412 {object} gin.H{"error":string}
+// @Router /transactions/{id} [patch]
+func PatchTransaction(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userID := c.GetUint("user_id")
+	var tx models.Transaction
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&tx).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if !ifMatch(c, tx.Version) {
+		return
+	}
+	current := []models.Transaction{tx}
+	if err := loadTags(config.DB, current); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	input := transactionInput(current[0])
+	if err := bindMergePatch(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Tags == nil {
+		// tags: null removes every tag
+		input.Tags = []string{}
+	}
+	updateTransaction(c, audit.ActionUpdate, tx, input)
+}
+
+// transactionInput returns the input that reproduces a stored transaction
+func transactionInput(tx models.Transaction) TransactionInput {
+	return TransactionInput{
+		Amount:      tx.Amount,
+		Date:        tx.Date.Format("2006-01-02"),
+		CategoryID:  tx.CategoryID,
+		AccountID:   tx.AccountID,
+		Payee:       tx.Payee,
+		Description: tx.Description,
+		Notes:       tx.Notes,
+		Tags:        tx.Tags,
+	}
+}
+
+// updateTransaction applies input to a stored transaction and responds with the result
+func updateTransaction(c *gin.Context, action string, tx models.Transaction, input TransactionInput) {
+	before := tx
+	if err := applyInput(&tx, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := config.DB.Transaction(func(db *gorm.DB) error { return saveTransaction(db, actorOf(c), action, &tx, &before) })
+	if errors.Is(err, errVersionConflict) {
+		var current models.Transaction
+		config.DB.Select("version").First(&current, tx.ID)
+		preconditionFailed(c, current.Version)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD."})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	tx.Amount = input.Amount
-	tx.Date = parsedDate
-	tx.CategoryID = input.CategoryID
-	tx.Description = input.Description
-	config.DB.Save(&tx)
-	c.JSON(http.StatusOK, tx)
+	checkBudgetAlerts(tx.UserID, tx.CategoryID, tx.Date)
+	txs := []models.Transaction{tx}
+	loadTags(config.DB, txs)
+	c.Header("ETag", etag(tx.Version))
+	c.JSON(http.StatusOK, txs[0])
 }
 
-// DeleteTransaction deletes a transaction for the authenticated user
+// DeleteTransaction moves a transaction of the authenticated user to the trash
 // @Summary Delete transaction
-// @Description Delete a transaction for the current user
+// @Description Move a transaction of the current user to the trash. It disappears from listings and reports and can be restored until it is purged.
 // @Tags transactions
 // @Security BearerAuth
 // @Param id path int true "Transaction ID"
@@ -129,66 +384,218 @@ func UpdateTransaction(c *gin.Context) {
 func DeleteTransaction(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	userID := c.GetUint("user_id")
-	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Transaction{}).Error; err != nil {
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		var tx models.Transaction
+		if err := db.Where("id = ? AND user_id = ?", id, userID).First(&tx).Error; err != nil {
+			return err
+		}
+		return deleteTransaction(db, actorOf(c), tx)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
-// ListTransactions returns all transactions for the authenticated user, with filters and pagination
+// TransactionPage is one page of ListTransactions
+type TransactionPage struct {
+	Data []models.Transaction `json:"data"`
+	// NextCursor fetches the following page; it is null on the last page
+	NextCursor *string `json:"next_cursor"`
+	// TotalCount and SumAmount cover every transaction matching the filters, not just this page
+	TotalCount int64   `json:"total_count"`
+	SumAmount  float64 `json:"sum_amount"`
+}
+
+// ListTransactions returns the transactions of the authenticated user, with filters, sorting and cursor pagination
 // @Summary List transactions
-// @Description Get all transactions for the current user, with optional filters and pagination
+// @Description Get a page of the current user's transactions with optional filters. Pages are keyset-paginated: pass next_cursor as cursor to fetch the following page, with the same filters and sort. With q, results are ordered by relevance unless sort is given and carry a highlighted snippet; relevance pages use an offset instead of a keyset, since scores change as transactions are added, so results may shift between pages.
 // @Tags transactions
 // @Security BearerAuth
 // @Produce json
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
 // @Param category_id query int false "Category ID"
+// @Param account query string false "Account ID or name"
+// @Param account_id query int false "Account ID (same as account)"
+// @Param payee query string false "Payee (case-insensitive)"
+// @Param tag query string false "Tag"
+// @Param q query string false "Full-text search over description, payee, notes, tags and category name; words match as prefixes"
 // @Param min_amount query number false "Minimum amount"
 // @Param max_amount query number false "Maximum amount"
-// @Param limit query int false "Limit"
-// @Param offset query int false "Offset"
-// @Success 200 {array} models.Transaction
+// @Param filter query string false "Filter expression, e.g. category in (1, 2) and not tag:reimbursed or amount < -500"
+// @Param view query int false "Saved view ID; its filter applies in addition to the other filters and its sort is the default"
+// @Param tz query string false "IANA timezone of relative dates in filter (default: the user's timezone)"
+// @Param sort query string false "date, amount, category or description, prefixed with - for descending (default: -date, or relevance with q)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} TransactionPage
+// @Failure 400 {object} gin.H{"error":string}
 // @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
 // @Router /transactions [get]
 func ListTransactions(c *gin.Context) {
 	userID := c.GetUint("user_id")
-	var txs []models.Transaction
-	query := config.DB.Where("user_id = ?", userID)
+	query := config.DB.Model(&models.Transaction{}).Where("transactions.user_id = ?", userID)
 	// Filtering (date, category, amount)
 	if start := c.Query("start_date"); start != "" {
-		query = query.Where("date >= ?", start)
+		query = query.Where("transactions.date >= ?", start)
 	}
 	if end := c.Query("end_date"); end != "" {
-		query = query.Where("date <= ?", end)
+		query = query.Where("transactions.date <= ?", end)
 	}
 	if cat := c.Query("category_id"); cat != "" {
-		query = query.Where("category_id = ?", cat)
+		query = query.Where("transactions.category_id = ?", cat)
+	}
+	acc, err := accountFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if acc != "" {
+		query = query.Where("transactions.account_id = ?", acc)
+	}
+	if payee := c.Query("payee"); payee != "" {
+		query = query.Where("LOWER(transactions.payee) = LOWER(?)", payee)
+	}
+	if tag := c.Query("tag"); tag != "" {
+		query = query.Where("transactions.id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tg.user_id = ? AND tg.name = ?)",
+			userID, strings.ToLower(strings.TrimSpace(tag)))
+	}
+	terms := search.Terms(c.Query("q"))
+	if len(terms) > 0 {
+		query = search.Filter(query, terms)
 	}
 	if min := c.Query("min_amount"); min != "" {
-		query = query.Where("amount >= ?", min)
+		query = query.Where("transactions.amount >= ?", min)
 	}
 	if max := c.Query("max_amount"); max != "" {
-		query = query.Where("amount <= ?", max)
+		query = query.Where("transactions.amount <= ?", max)
 	}
-	// Pagination
-	limit := 20
-	offset := 0
+	sortParam := c.Query("sort")
+	var expressions []string
+	if id := c.Query("view"); id != "" {
+		var view models.SavedView
+		if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&view).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+			return
+		}
+		expressions = append(expressions, view.Filter)
+		if sortParam == "" {
+			sortParam = view.Sort
+		}
+	}
+	if expr := c.Query("filter"); expr != "" {
+		expressions = append(expressions, expr)
+	}
+	if len(expressions) > 0 {
+		loc, err := userLocation(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for _, expr := range expressions {
+			node, err := filter.Parse(expr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			cond, args, err := filter.SQL(node, userID, today(loc))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			query = query.Where(cond, args...)
+		}
+	}
+	sort, err := parseTransactionSort(sortParam, len(terms) > 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := defaultPageSize
 	if l := c.Query("limit"); l != "" {
-		if v, err := strconv.Atoi(l); err == nil {
-			limit = v
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
 		}
+		limit = min(v, maxPageSize)
 	}
-	if o := c.Query("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil {
-			offset = v
+
+	page := TransactionPage{Data: []models.Transaction{}}
+	var total struct {
+		Count int64
+		Sum   float64
+	}
+	if err := query.Session(&gorm.Session{}).Select("COUNT(*) AS count, COALESCE(SUM(transactions.amount), 0) AS sum").Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	page.TotalCount, page.SumAmount = total.Count, total.Sum
+
+	offset := 0
+	if sort.Key == sortRelevance {
+		query = search.Ranked(query).Order("transactions.date DESC").Order("transactions.id DESC")
+	} else {
+		if sort.Key == "category" {
+			query = query.Joins("LEFT JOIN categories c ON c.id = transactions.category_id")
+		}
+		query = sort.order(query)
+	}
+	if cur := c.Query("cursor"); cur != "" {
+		cursor, err := decodeCursor(cur, sort)
+		if err == nil && sort.Key != sortRelevance {
+			query, err = sort.after(query, cursor)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+		offset = cursor.Offset
 	}
-	query = query.Limit(limit).Offset(offset)
-	if err := query.Order("date desc").Find(&txs).Error; err != nil {
+	// one extra row tells whether there is a next page
+	if err := query.Limit(limit + 1).Offset(offset).Find(&page.Data).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, txs)
+	if len(page.Data) > limit {
+		page.Data = page.Data[:limit]
+		last := page.Data[limit-1]
+		var next string
+		if sort.Key == sortRelevance {
+			next = encodeCursor(listCursor{Sort: sort.String(), Offset: offset + limit})
+		} else {
+			var categoryName string
+			if sort.Key == "category" {
+				config.DB.Unscoped().Model(&models.Category{}).Where("id = ?", last.CategoryID).Select("name").Scan(&categoryName)
+			}
+			next = sort.cursorAfter(last, categoryName)
+		}
+		page.NextCursor = &next
+	}
+	if err := loadTags(config.DB, page.Data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(terms) > 0 {
+		ids := make([]uint, len(page.Data))
+		for i, tx := range page.Data {
+			ids[i] = tx.ID
+		}
+		snippets, err := search.Snippets(config.DB, terms, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range page.Data {
+			page.Data[i].Snippet = snippets[page.Data[i].ID]
+		}
+	}
+	c.JSON(http.StatusOK, page)
 }
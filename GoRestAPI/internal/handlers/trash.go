@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/rollup"
+	"expense-tracker/internal/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TrashedTransaction is a deleted transaction that can still be restored
+type TrashedTransaction struct {
+	models.Transaction
+	DeletedAt time.Time `json:"deleted_at"`
+	// PurgeAt is when the transaction is deleted for good
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// TrashedCategory is a deleted category that can still be restored
+type TrashedCategory struct {
+	models.Category
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type Trash struct {
+	Transactions []TrashedTransaction `json:"transactions"`
+	Categories   []TrashedCategory    `json:"categories"`
+}
+
+// errCategoryTrashed is returned when a transaction is restored into a trashed category
+var errCategoryTrashed = errors.New("The transaction's category is in the trash; restore the category first")
+
+// ListTrash returns the deleted transactions and categories of the authenticated user
+// @Summary List trash
+// @Description Get the transactions and categories of the current user that were deleted and can still be restored, most recently deleted first. Each entry says when it will be purged.
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} Trash
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /trash [get]
+func ListTrash(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var txs []models.Transaction
+	if err := config.DB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at DESC, id DESC").Find(&txs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := loadTags(config.DB, txs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var cats []models.Category
+	if err := config.DB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at DESC, id DESC").Find(&cats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	retention := config.AppConfig.TrashRetention
+	trash := Trash{Transactions: []TrashedTransaction{}, Categories: []TrashedCategory{}}
+	for _, tx := range txs {
+		deleted := tx.DeletedAt.Time
+		trash.Transactions = append(trash.Transactions, TrashedTransaction{tx, deleted, deleted.Add(retention)})
+	}
+	for _, cat := range cats {
+		deleted := cat.DeletedAt.Time
+		trash.Categories = append(trash.Categories, TrashedCategory{cat, deleted, deleted.Add(retention)})
+	}
+	c.JSON(http.StatusOK, trash)
+}
+
+// RestoreTransaction takes a transaction of the authenticated user out of the trash
+// @Summary Restore transaction
+// @Description Restore a deleted transaction with its tags. Its category must not be in the trash; an account deleted in the meantime is cleared.
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} models.Transaction
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 409 {object} gin.H{"error":string}
+// @Router /transactions/{id}/restore [post]
+func RestoreTransaction(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var tx models.Transaction
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		if err := db.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", c.Param("id"), userID).First(&tx).Error; err != nil {
+			return err
+		}
+		return restoreTransaction(db, actorOf(c), &tx)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found in trash"})
+		return
+	}
+	if errors.Is(err, errCategoryTrashed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	checkBudgetAlerts(userID, tx.CategoryID, tx.Date)
+	txs := []models.Transaction{tx}
+	loadTags(config.DB, txs)
+	c.Header("ETag", etag(tx.Version))
+	c.JSON(http.StatusOK, txs[0])
+}
+
+// restoreTransaction takes a trashed transaction out of the trash and adds it
+// back to the monthly rollups and the search index. It fails with
+// gorm.ErrRecordNotFound when the transaction was restored in the meantime, so
+// that a concurrent restore does not count it twice.
+func restoreTransaction(db *gorm.DB, actor audit.Actor, tx *models.Transaction) error {
+	before, err := withTags(db, *tx)
+	if err != nil {
+		return err
+	}
+	var n int64
+	db.Model(&models.Category{}).Where("id = ?", tx.CategoryID).Count(&n)
+	if n == 0 {
+		return errCategoryTrashed
+	}
+	if tx.AccountID != nil {
+		db.Model(&models.Account{}).Where("id = ?", *tx.AccountID).Count(&n)
+		if n == 0 {
+			tx.AccountID = nil
+		}
+	}
+	tx.Version++
+	tx.DeletedAt = gorm.DeletedAt{}
+	result := db.Unscoped().Model(tx).Where("deleted_at IS NOT NULL").Updates(map[string]interface{}{"deleted_at": nil, "account_id": tx.AccountID, "version": tx.Version})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if err := rollup.Add(db, *tx); err != nil {
+		return err
+	}
+	if err := search.Index(db, tx.ID); err != nil {
+		return err
+	}
+	return recordTransaction(db, actor, audit.ActionRestore, before, tx)
+}
+
+// RestoreCategory takes a category of the authenticated user out of the trash
+// @Summary Restore category
+// @Description Restore a deleted category. Restoring does not re-attach the subcategories that were detached when it was deleted; they stay top-level. If its own parent is gone it becomes top-level.
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} models.Category
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /categories/{id}/restore [post]
+func RestoreCategory(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var cat models.Category
+	if err := config.DB.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", c.Param("id"), userID).First(&cat).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found in trash"})
+		return
+	}
+	before := cat
+	if validateParent(userID, cat.ID, cat.ParentID) != nil {
+		cat.ParentID = nil
+	}
+	cat.Version++
+	cat.DeletedAt = gorm.DeletedAt{}
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		err := db.Unscoped().Model(&cat).Updates(map[string]interface{}{"deleted_at": nil, "parent_id": cat.ParentID, "version": cat.Version}).Error
+		if err != nil {
+			return err
+		}
+		return audit.Record(db, actorOf(c), audit.ActionRestore, audit.EntityCategory, cat.ID, cat.Version, before, cat)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("ETag", etag(cat.Version))
+	c.JSON(http.StatusOK, cat)
+}
+
+// PurgeTrash deletes for good the transactions and categories that have been
+// in the trash for longer than the configured retention, recording a purge
+// entry for each in the audit log. A category that transactions or recurring
+// transactions still refer to is kept until they are moved or purged; its
+// budgets and envelope assignments are deleted with it.
+func PurgeTrash(now time.Time) error {
+	cutoff := now.Add(-config.AppConfig.TrashRetention)
+	return config.DB.Transaction(func(db *gorm.DB) error {
+		var txs []models.Transaction
+		if err := db.Unscoped().Where("deleted_at <= ?", cutoff).Find(&txs).Error; err != nil {
+			return err
+		}
+		if len(txs) > 0 {
+			if err := loadTags(db, txs); err != nil {
+				return err
+			}
+			ids := make([]uint, len(txs))
+			for i, tx := range txs {
+				ids[i] = tx.ID
+				if err := audit.Record(db, audit.Actor{}, audit.ActionPurge, audit.EntityTransaction, tx.ID, tx.Version, tx, nil); err != nil {
+					return err
+				}
+			}
+			if err := db.Where("transaction_id IN ?", ids).Delete(&models.TransactionTag{}).Error; err != nil {
+				return err
+			}
+			if err := db.Unscoped().Where("id IN ?", ids).Delete(&models.Transaction{}).Error; err != nil {
+				return err
+			}
+		}
+		var cats []models.Category
+		err := db.Unscoped().
+			Where("deleted_at <= ? AND id NOT IN (SELECT category_id FROM transactions) AND id NOT IN (SELECT category_id FROM recurring_transactions)", cutoff).
+			Find(&cats).Error
+		if err != nil || len(cats) == 0 {
+			return err
+		}
+		ids := make([]uint, len(cats))
+		for i, cat := range cats {
+			ids[i] = cat.ID
+			if err := audit.Record(db, audit.Actor{}, audit.ActionPurge, audit.EntityCategory, cat.ID, cat.Version, cat, nil); err != nil {
+				return err
+			}
+		}
+		budgets := db.Model(&models.Budget{}).Select("id").Where("category_id IN ?", ids)
+		if err := db.Where("budget_id IN (?)", budgets).Delete(&models.BudgetAlert{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("category_id IN ?", ids).Delete(&models.Budget{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("category_id IN ?", ids).Delete(&models.EnvelopeAssignment{}).Error; err != nil {
+			return err
+		}
+		return db.Unscoped().Where("id IN ?", ids).Delete(&models.Category{}).Error
+	})
+}
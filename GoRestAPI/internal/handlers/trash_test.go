@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/audit"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/rollup"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTrashAndRestoreTransaction(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "trash@example.com")
+	food := models.Category{UserID: user.ID, Name: "Food", Kind: models.CategoryKindExpense, Version: 1}
+	config.DB.Create(&food)
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	kept := models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: -20, Date: date, Version: 1}
+	trashed := models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: -30, Date: date, Version: 1}
+	config.DB.Create(&kept)
+	config.DB.Create(&trashed)
+	assert.NoError(t, rollup.Rebuild(config.DB, user.ID))
+	id := strconv.Itoa(int(trashed.ID))
+
+	list := func() TransactionPage {
+		w := serve(user.ID, ListTransactions, http.MethodGet, "/transactions", "/transactions", "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page TransactionPage
+		json.Unmarshal(w.Body.Bytes(), &page)
+		return page
+	}
+	spent := func() float64 {
+		w := serve(user.ID, GetSummary, http.MethodGet, "/reports/summary", "/reports/summary?start_date=2025-03-01&end_date=2025-03-31", "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp SummaryResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.TotalExpense
+	}
+	assert.Equal(t, -50.0, spent())
+
+	w := serve(user.ID, DeleteTransaction, http.MethodDelete, "/transactions/:id", "/transactions/"+id, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	page := list()
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, kept.ID, page.Data[0].ID)
+	assert.Equal(t, -20.0, spent())
+
+	w = serve(user.ID, ListTrash, http.MethodGet, "/trash", "/trash", "")
+	var trash Trash
+	json.Unmarshal(w.Body.Bytes(), &trash)
+	assert.Len(t, trash.Transactions, 1)
+	assert.Equal(t, trashed.ID, trash.Transactions[0].ID)
+
+	w = serve(user.ID, RestoreTransaction, http.MethodPost, "/transactions/:id/restore", "/transactions/"+id+"/restore", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Len(t, list().Data, 2)
+	assert.Equal(t, -50.0, spent())
+
+	// a second restore finds nothing and does not count the transaction twice
+	w = serve(user.ID, RestoreTransaction, http.MethodPost, "/transactions/:id/restore", "/transactions/"+id+"/restore", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, -50.0, spent())
+	// as does a restore that loaded the transaction before another one finished
+	assert.ErrorIs(t, restoreTransaction(config.DB, audit.Actor{}, &trashed), gorm.ErrRecordNotFound)
+	assert.Equal(t, -50.0, spent())
+
+	// the transactions of a trashed category still count
+	w = serve(user.ID, DeleteCategory, http.MethodDelete, "/categories/:id", "/categories/"+strconv.Itoa(int(food.ID)), "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, list().Data, 2)
+	assert.Equal(t, -50.0, spent())
+}
+
+func TestTrashedCategoryStillCounts(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "trashed-counts@example.com")
+	food := models.Category{UserID: user.ID, Name: "Food", Kind: models.CategoryKindExpense, Version: 1}
+	config.DB.Create(&food)
+	market := models.Category{UserID: user.ID, Name: "Market", Kind: models.CategoryKindExpense, ParentID: &food.ID, Version: 1}
+	config.DB.Create(&market)
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	for _, tx := range []models.Transaction{
+		{CategoryID: food.ID, Amount: -40, Date: date},
+		{CategoryID: market.ID, Amount: -50, Date: date},
+	} {
+		tx.UserID, tx.Version = user.ID, 1
+		config.DB.Create(&tx)
+	}
+	budget := models.Budget{UserID: user.ID, CategoryID: food.ID, Period: models.BudgetPeriodMonthly, Amount: 100, StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	config.DB.Create(&budget)
+	config.DB.Delete(&market)
+	config.DB.Delete(&food)
+
+	w := serve(user.ID, GetBudgetReport, http.MethodGet, "/reports/budget", "/reports/budget?start_date=2025-03-01&end_date=2025-03-31", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report []BudgetStatus
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if assert.Len(t, report, 1) {
+		assert.Equal(t, "Food", report[0].CategoryName)
+		assert.Equal(t, 90.0, report[0].Spent, "including the trashed subcategory")
+	}
+
+	checkBudgetAlerts(user.ID, market.ID, date)
+	var alerts int64
+	config.DB.Model(&models.BudgetAlert{}).Where("budget_id = ?", budget.ID).Count(&alerts)
+	assert.Equal(t, int64(1), alerts)
+}
+
+func TestRestoreTransactionIntoTrashedCategory(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "trash-category@example.com")
+	food := models.Category{UserID: user.ID, Name: "Food", Kind: models.CategoryKindExpense, Version: 1}
+	config.DB.Create(&food)
+	tx := models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: -20, Date: time.Now(), Version: 1}
+	config.DB.Create(&tx)
+	id := strconv.Itoa(int(tx.ID))
+
+	serve(user.ID, DeleteTransaction, http.MethodDelete, "/transactions/:id", "/transactions/"+id, "")
+	w := serve(user.ID, DeleteCategory, http.MethodDelete, "/categories/:id", "/categories/"+strconv.Itoa(int(food.ID)), "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = serve(user.ID, RestoreTransaction, http.MethodPost, "/transactions/:id/restore", "/transactions/"+id+"/restore", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serve(user.ID, RestoreCategory, http.MethodPost, "/categories/:id/restore", "/categories/"+strconv.Itoa(int(food.ID))+"/restore", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(user.ID, RestoreTransaction, http.MethodPost, "/transactions/:id/restore", "/transactions/"+id+"/restore", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t, "purge@example.com")
+	now := time.Now().UTC()
+	old := now.Add(-config.AppConfig.TrashRetention - time.Hour)
+	recent := now.Add(-time.Hour)
+
+	used := models.Category{UserID: user.ID, Name: "Used", Version: 1}
+	unused := models.Category{UserID: user.ID, Name: "Unused", Version: 1}
+	fresh := models.Category{UserID: user.ID, Name: "Fresh", Version: 1}
+	for _, cat := range []*models.Category{&used, &unused, &fresh} {
+		config.DB.Create(cat)
+	}
+	live := models.Transaction{UserID: user.ID, CategoryID: used.ID, Amount: -5, Date: now, Version: 1}
+	expired := models.Transaction{UserID: user.ID, CategoryID: unused.ID, Amount: -5, Date: now, Version: 1}
+	pending := models.Transaction{UserID: user.ID, CategoryID: fresh.ID, Amount: -5, Date: now, Version: 1}
+	for _, tx := range []*models.Transaction{&live, &expired, &pending} {
+		config.DB.Create(tx)
+	}
+	config.DB.Create(&models.TransactionTag{TransactionID: expired.ID, TagID: 1})
+	budget := models.Budget{UserID: user.ID, CategoryID: unused.ID, Period: models.BudgetPeriodMonthly, Amount: 10, StartDate: now}
+	config.DB.Create(&budget)
+	config.DB.Create(&models.BudgetAlert{BudgetID: budget.ID, Threshold: 80, PeriodStart: now})
+	config.DB.Create(&models.EnvelopeAssignment{UserID: user.ID, CategoryID: unused.ID, Month: "2025-01", Amount: 10})
+	scheduled := models.Category{UserID: user.ID, Name: "Scheduled", Version: 1}
+	config.DB.Create(&scheduled)
+	config.DB.Create(&models.RecurringTransaction{UserID: user.ID, CategoryID: scheduled.ID, Amount: -5, Frequency: models.FrequencyMonthly, StartDate: now})
+	trash := func(model interface{}, id uint, at time.Time) {
+		config.DB.Unscoped().Model(model).Where("id = ?", id).Update("deleted_at", at)
+	}
+	trash(&models.Category{}, used.ID, old)
+	trash(&models.Category{}, unused.ID, old)
+	trash(&models.Category{}, fresh.ID, recent)
+	trash(&models.Category{}, scheduled.ID, old)
+	trash(&models.Transaction{}, expired.ID, old)
+	trash(&models.Transaction{}, pending.ID, recent)
+
+	assert.NoError(t, PurgeTrash(now))
+
+	exists := func(model interface{}, id uint) bool {
+		var n int64
+		config.DB.Unscoped().Model(model).Where("id = ?", id).Count(&n)
+		return n > 0
+	}
+	assert.False(t, exists(&models.Transaction{}, expired.ID))
+	assert.True(t, exists(&models.Transaction{}, pending.ID))
+	assert.True(t, exists(&models.Transaction{}, live.ID))
+	var tags int64
+	config.DB.Model(&models.TransactionTag{}).Where("transaction_id = ?", expired.ID).Count(&tags)
+	assert.Zero(t, tags)
+	assert.False(t, exists(&models.Category{}, unused.ID))
+	assert.True(t, exists(&models.Category{}, fresh.ID))
+	// a live transaction or recurring transaction still refers to them
+	assert.True(t, exists(&models.Category{}, used.ID))
+	assert.True(t, exists(&models.Category{}, scheduled.ID))
+	// the budgets and envelope assignments of a purged category go with it
+	assert.False(t, exists(&models.Budget{}, budget.ID))
+	var left int64
+	config.DB.Model(&models.BudgetAlert{}).Where("budget_id = ?", budget.ID).Count(&left)
+	assert.Zero(t, left)
+	config.DB.Model(&models.EnvelopeAssignment{}).Where("category_id = ?", unused.ID).Count(&left)
+	assert.Zero(t, left)
+	var purged []models.AuditEntry
+	config.DB.Where("action = ?", audit.ActionPurge).Order("id").Find(&purged)
+	if assert.Len(t, purged, 2) {
+		assert.Equal(t, audit.EntityTransaction, purged[0].EntityType)
+		assert.Equal(t, expired.ID, purged[0].EntityID)
+		assert.Equal(t, audit.EntityCategory, purged[1].EntityType)
+		assert.Equal(t, unused.ID, purged[1].EntityID)
+		assert.Zero(t, purged[1].ActorID)
+	}
+
+	// once the transaction is moved away the category goes too
+	other := models.Category{UserID: user.ID, Name: "Other", Version: 1}
+	config.DB.Create(&other)
+	config.DB.Model(&live).Update("category_id", other.ID)
+	assert.NoError(t, PurgeTrash(now))
+	assert.False(t, exists(&models.Category{}, used.ID))
+}
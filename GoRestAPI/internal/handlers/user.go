@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/audit"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UserProfile struct {
+	ID            uint   `json:"id"`
+	Email         string `json:"email"`
+	IsAdmin       bool   `json:"is_admin"`
+	BudgetMode    string `json:"budget_mode"`
+	Timezone      string `json:"timezone"`
+	AnomalyAlerts bool   `json:"anomaly_alerts"`
+}
+
+type UserSettingsInput struct {
+	BudgetMode    string `json:"budget_mode" binding:"omitempty,oneof=standard envelope"`
+	Timezone      string `json:"timezone"`
+	AnomalyAlerts *bool  `json:"anomaly_alerts"`
+}
+
+func profileOf(user models.User) UserProfile {
+	mode := user.BudgetMode
+	if mode == "" {
+		mode = models.BudgetModeStandard
+	}
+	tz := user.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	return UserProfile{ID: user.ID, Email: user.Email, IsAdmin: user.IsAdmin, BudgetMode: mode, Timezone: tz, AnomalyAlerts: user.AnomalyAlerts}
+}
+
+// GetCurrentUser returns the profile and settings of the authenticated user
+// @Summary Get current user
+// @Description Get the profile and settings of the current user
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} UserProfile
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /me [get]
+func GetCurrentUser(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	c.JSON(http.StatusOK, profileOf(user))
+}
+
+// UpdateCurrentUser updates the settings of the authenticated user
+// @Summary Update current user settings
+// @Description Update the settings of the current user. Omitted fields are left unchanged.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body UserSettingsInput true "User settings"
+// @Success 200 {object} UserProfile
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /me [put]
+func UpdateCurrentUser(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var input UserSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := profileOf(user)
+	if input.BudgetMode != "" {
+		user.BudgetMode = input.BudgetMode
+	}
+	if input.Timezone != "" {
+		if _, err := time.LoadLocation(input.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone " + input.Timezone})
+			return
+		}
+		user.Timezone = input.Timezone
+	}
+	if input.AnomalyAlerts != nil {
+		user.AnomalyAlerts = *input.AnomalyAlerts
+	}
+	err := config.DB.Transaction(func(db *gorm.DB) error {
+		if err := db.Save(&user).Error; err != nil {
+			return err
+		}
+		return audit.Record(db, actorOf(c), audit.ActionUpdate, audit.EntityUser, user.ID, 0, before, profileOf(user))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profileOf(user))
+}
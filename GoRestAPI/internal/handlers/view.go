@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/filter"
+	"github.com/gin-gonic/gin"
+)
+
+type ViewInput struct {
+	Name   string `json:"name" binding:"required,max=100"`
+	Filter string `json:"filter" binding:"required"`
+	Sort   string `json:"sort"`
+}
+
+// validate checks the filter expression and sort of a view
+func (in *ViewInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return errors.New("Name must not be empty")
+	}
+	if _, err := filter.Parse(in.Filter); err != nil {
+		return err
+	}
+	if in.Sort != "" {
+		if _, err := parseTransactionSort(in.Sort, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// viewNameTaken reports whether the user has another view with the name
+func viewNameTaken(userID, id uint, name string) bool {
+	var n int64
+	config.DB.Model(&models.SavedView{}).Where("user_id = ? AND name = ? AND id != ?", userID, name, id).Count(&n)
+	return n > 0
+}
+
+// ListViews returns the saved views of the authenticated user
+// @Summary List saved views
+// @Description Get the saved transaction filters of the current user
+// @Tags views
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.SavedView
+// @Failure 401 {object} gin.H{"error":string}
+// @Router /views [get]
+func ListViews(c *gin.Context) {
+	views := []models.SavedView{}
+	if err := config.DB.Where("user_id = ?", c.GetUint("user_id")).Order("name").Find(&views).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// CreateView saves a named transaction filter for the authenticated user
+// @Summary Create saved view
+// @Description Save a filter expression, and optionally a sort, under a name. Apply it with GET /transactions?view={id}.
+// @Tags views
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body ViewInput true "View"
+// @Success 201 {object} models.SavedView
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 409 {object} gin.H{"error":string}
+// @Router /views [post]
+func CreateView(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	var input ViewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if viewNameTaken(userID, 0, input.Name) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A view with this name already exists"})
+		return
+	}
+	view := models.SavedView{UserID: userID, Name: input.Name, Filter: input.Filter, Sort: input.Sort}
+	if err := config.DB.Create(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, view)
+}
+
+// UpdateView updates a saved view of the authenticated user
+// @Summary Update saved view
+// @Description Rename a saved view or change its filter or sort
+// @Tags views
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "View ID"
+// @Param input body ViewInput true "View"
+// @Success 200 {object} models.SavedView
+// @Failure 400 {object} gin.H{"error":string}
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Failure 409 {object} gin.H{"error":string}
+// @Router /views/{id} [put]
+func UpdateView(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, _ := strconv.Atoi(c.Param("id"))
+	var view models.SavedView
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	var input ViewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if viewNameTaken(userID, view.ID, input.Name) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A view with this name already exists"})
+		return
+	}
+	view.Name, view.Filter, view.Sort = input.Name, input.Filter, input.Sort
+	if err := config.DB.Save(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// DeleteView deletes a saved view of the authenticated user
+// @Summary Delete saved view
+// @Description Delete a saved view; transactions are not affected
+// @Tags views
+// @Security BearerAuth
+// @Param id path int true "View ID"
+// @Success 204 {string} string ""
+// @Failure 401 {object} gin.H{"error":string}
+// @Failure 404 {object} gin.H{"error":string}
+// @Router /views/{id} [delete]
+func DeleteView(c *gin.Context) {
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).Delete(&models.SavedView{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
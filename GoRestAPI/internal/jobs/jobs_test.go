@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartRunsImmediatelyAndRepeats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan time.Time, 10)
+	Start(ctx, Job{Name: "test", Interval: 10 * time.Millisecond, Run: func(now time.Time) error {
+		runs <- now
+		return nil
+	}})
+	for i := 0; i < 2; i++ {
+		select {
+		case now := <-runs:
+			assert.Equal(t, time.UTC, now.Location())
+		case <-time.After(time.Second):
+			t.Fatal("job did not run")
+		}
+	}
+	cancel()
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOnlyMiddleware rejects requests from users without admin rights.
+// It must run after JWTAuthMiddleware.
+func AdminOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := config.DB.First(&user, c.GetUint("user_id")).Error; err != nil || !user.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
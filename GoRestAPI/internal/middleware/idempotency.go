@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+	"expense-tracker/internal/models"
+	"expense-tracker/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// maxIdempotencyKeyLength bounds the length of an Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored besides Content-Type and sent again on a replay
+var replayedHeaders = []string{"ETag", "Location"}
+
+// responseRecorder keeps a copy of the response body
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// fingerprint identifies a request by its method, path with query string, and body
+func fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// IdempotencyMiddleware makes POST requests that carry an Idempotency-Key
+// header safe to retry. The first request with a key runs normally and its
+// response is stored for config.AppConfig.IdempotencyTTL; retries with the same
+// key and body get the stored response back, with the Idempotent-Replayed
+// header, instead of running again. Reusing a key for a different request is
+// rejected with 422 and a retry that arrives while the first request is still
+// running gets 409. Server errors are not stored, so such requests can be
+// retried with the same key. Keys are scoped to the user, so it must run after
+// JWTAuthMiddleware; anonymous requests, which would all share one key space,
+// are passed through untouched.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		userID := c.GetUint("user_id")
+		if c.Request.Method != http.MethodPost || key == "" || userID == 0 {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now().UTC()
+		record := models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint(c.Request.Method, c.Request.URL.RequestURI(), body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(config.AppConfig.IdempotencyTTL),
+		}
+		// an expired key may be used again even before the purge job removed it
+		config.DB.Where("user_id = ? AND key = ? AND expires_at <= ?", userID, key, now).Delete(&models.IdempotencyKey{})
+		result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			replay(c, userID, key, record.Fingerprint)
+			return
+		}
+
+		w := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		stored := false
+		defer func() {
+			// release the key when the handler failed or panicked
+			if !stored {
+				config.DB.Delete(&record)
+			}
+		}()
+		c.Next()
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if v := w.Header().Get(name); v != "" {
+				headers[name] = v
+			}
+		}
+		encoded, _ := json.Marshal(headers)
+		stored = config.DB.Model(&record).Updates(map[string]interface{}{
+			"status":       w.Status(),
+			"content_type": w.Header().Get("Content-Type"),
+			"headers":      string(encoded),
+			"body":         w.body.Bytes(),
+		}).Error == nil
+	}
+}
+
+// replay answers a request whose key is already stored
+func replay(c *gin.Context, userID uint, key, fingerprint string) {
+	var stored models.IdempotencyKey
+	if err := config.DB.Where("user_id = ? AND key = ?", userID, key).First(&stored).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	switch {
+	case stored.Fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case stored.Status == 0:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		var headers map[string]string
+		json.Unmarshal([]byte(stored.Headers), &headers)
+		for name, v := range headers {
+			c.Header(name, v)
+		}
+		if stored.ContentType == "" {
+			c.AbortWithStatus(stored.Status)
+			return
+		}
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+		c.Abort()
+	}
+}
+
+// PurgeIdempotencyKeys deletes stored responses whose TTL has passed
+func PurgeIdempotencyKeys(now time.Time) error {
+	return config.DB.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	body := []byte(`{"amount":-7}`)
+	assert.Equal(t, fingerprint("POST", "/transactions", body), fingerprint("POST", "/transactions", []byte(`{"amount":-7}`)))
+	assert.NotEqual(t, fingerprint("POST", "/transactions", body), fingerprint("POST", "/transactions", []byte(`{"amount":-8}`)))
+	assert.NotEqual(t, fingerprint("POST", "/transactions", body), fingerprint("POST", "/budgets", body))
+	assert.NotEqual(t, fingerprint("POST", "/digests/1/send", body), fingerprint("POST", "/digests/1/send?preview=true", body))
+}
+
+func TestIdempotencyMiddlewareWithoutKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	calls := 0
+	r.POST("/t", func(c *gin.Context) { c.Set("user_id", uint(1)) }, IdempotencyMiddleware(), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/t", strings.NewReader("{}")))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	assert.Equal(t, 2, calls)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/t", strings.NewReader("{}"))
+	req.Header.Set("Idempotency-Key", strings.Repeat("k", maxIdempotencyKeyLength+1))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyMiddlewareSkipsAnonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	calls := 0
+	r.POST("/t", IdempotencyMiddleware(), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/t", strings.NewReader(`{"password":"secret"}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.LoadConfig()
+	config.AppConfig.DBPath = filepath.Join(t.TempDir(), "test.db")
+	config.InitDB()
+	t.Cleanup(func() {
+		if db, err := config.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+
+	r := gin.New()
+	r.POST("/tags", func(c *gin.Context) { c.Set("user_id", uint(1)) }, IdempotencyMiddleware(), func(c *gin.Context) {
+		var tag models.Tag
+		c.ShouldBindJSON(&tag)
+		tag.UserID = 1
+		if err := config.DB.Create(&tag).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("ETag", `"1"`)
+		c.Header("Location", "/tags/"+strconv.Itoa(int(tag.ID)))
+		c.JSON(http.StatusCreated, tag)
+	})
+	post := func(key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/tags", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	tags := func() int64 {
+		var n int64
+		config.DB.Model(&models.Tag{}).Count(&n)
+		return n
+	}
+
+	first := post("k1", `{"name":"travel"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// a retry replays the stored response, headers included, without running again
+	retry := post("k1", `{"name":"travel"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", retry.Header().Get("Content-Type"))
+	assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("Location"), retry.Header().Get("Location"))
+	assert.Equal(t, int64(1), tags())
+
+	// the same key for a different request
+	assert.Equal(t, http.StatusUnprocessableEntity, post("k1", `{"name":"food"}`).Code)
+	assert.Equal(t, int64(1), tags())
+
+	// a retry while the first request is still running
+	config.DB.Create(&models.IdempotencyKey{UserID: 1, Key: "k2", Fingerprint: fingerprint(http.MethodPost, "/tags", []byte(`{"name":"food"}`)), ExpiresAt: time.Now().Add(time.Hour)})
+	assert.Equal(t, http.StatusConflict, post("k2", `{"name":"food"}`).Code)
+	assert.Equal(t, int64(1), tags())
+
+	// server errors are not stored: the duplicate name fails, then the retry runs again
+	assert.Equal(t, http.StatusInternalServerError, post("k3", `{"name":"travel"}`).Code)
+	config.DB.Model(&models.Tag{}).Where("name = ?", "travel").Update("name", "trips")
+	w := post("k3", `{"name":"travel"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int64(2), tags())
+
+	// an expired key runs the request again
+	config.DB.Model(&models.IdempotencyKey{}).Where("key = ?", "k1").Update("expires_at", time.Now().Add(-time.Minute))
+	config.DB.Where("name = ?", "travel").Delete(&models.Tag{})
+	w = post("k1", `{"name":"travel"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int64(2), tags())
+}
@@ -0,0 +1,21 @@
+package models
+
+// Account types
+const (
+	AccountTypeChecking   = "checking"
+	AccountTypeSavings    = "savings"
+	AccountTypeCredit     = "credit"
+	AccountTypeCash       = "cash"
+	AccountTypeInvestment = "investment"
+)
+
+// Account is where money is held. Its balance is the opening balance plus the
+// amounts of the transactions filed under it.
+type Account struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	UserID         uint    `gorm:"not null" json:"user_id"`
+	Name           string  `gorm:"not null" json:"name"`
+	Type           string  `gorm:"not null" json:"type"`
+	OpeningBalance float64 `gorm:"not null;default:0" json:"opening_balance"`
+	Archived       bool    `gorm:"not null;default:false" json:"archived"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Asset kinds
+const (
+	AssetKindAsset     = "asset"
+	AssetKindLiability = "liability"
+)
+
+// Asset is something owned (house, car, investments) or owed (loan, mortgage)
+// that is tracked outside accounts through dated valuations.
+type Asset struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Kind      string    `gorm:"not null" json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AssetValuation is the value of an asset, or the outstanding amount of a
+// liability, as of a date. It stays in effect until the next valuation.
+type AssetValuation struct {
+	ID      uint      `gorm:"primaryKey" json:"id"`
+	AssetID uint      `gorm:"not null;uniqueIndex:idx_asset_valuations_date" json:"asset_id"`
+	Date    time.Time `gorm:"not null;uniqueIndex:idx_asset_valuations_date" json:"date"`
+	Value   float64   `gorm:"not null" json:"value"`
+}
+
+// NetWorthSnapshot records a user's net worth at the end of a month, so
+// history does not change when past transactions or valuations are edited.
+type NetWorthSnapshot struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_net_worth_snapshots_date" json:"user_id"`
+	Date        time.Time `gorm:"not null;uniqueIndex:idx_net_worth_snapshots_date" json:"date"`
+	Accounts    float64   `gorm:"not null" json:"accounts"`
+	Assets      float64   `gorm:"not null" json:"assets"`
+	Liabilities float64   `gorm:"not null" json:"liabilities"`
+	NetWorth    float64   `gorm:"not null" json:"net_worth"`
+	CreatedAt   time.Time `json:"created_at"`
+}
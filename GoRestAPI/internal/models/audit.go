@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry records one write: who made it, from where, and the entity
+// before and after. Entries are append-only; the table rejects updates and
+// deletes.
+type AuditEntry struct {
+	ID      uint `gorm:"primaryKey" json:"id"`
+	ActorID uint `gorm:"not null;index" json:"actor_id"`
+	// Action is what happened, e.g. create, update, delete, restore, revert, purge or login
+	Action     string `gorm:"not null" json:"action"`
+	EntityType string `gorm:"not null;index:idx_audit_log_entity" json:"entity_type"`
+	EntityID   uint   `gorm:"not null;index:idx_audit_log_entity" json:"entity_id"`
+	// Version is the version of the entity after the change, for versioned entities
+	Version uint   `gorm:"not null;default:0" json:"version,omitempty"`
+	IP      string `json:"ip"`
+	// Before and After are JSON snapshots of the entity; Before is null for a
+	// create and After for a delete
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+	// Changes maps each changed field to its old and new value
+	Changes   json.RawMessage `json:"changes"`
+	CreatedAt time.Time       `gorm:"index" json:"created_at"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
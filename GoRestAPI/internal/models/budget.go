@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+// Budget periods
+const (
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodYearly  = "yearly"
+	BudgetPeriodCustom  = "custom"
+)
+
+// Budget limits spending in a category, including its child categories, per period.
+// Weekly periods start on Monday, monthly and yearly periods follow the calendar.
+// A custom budget covers the single period [StartDate, EndDate].
+type Budget struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null" json:"user_id"`
+	CategoryID uint       `gorm:"not null" json:"category_id"`
+	Period     string     `gorm:"not null" json:"period"`
+	Amount     float64    `gorm:"not null" json:"amount"`
+	Rollover   bool       `gorm:"not null;default:false" json:"rollover"`
+	StartDate  time.Time  `gorm:"not null" json:"start_date"`
+	EndDate    *time.Time `json:"end_date"`
+}
+
+// PeriodAt returns the budget period [start, end) containing t.
+func (b Budget) PeriodAt(t time.Time) (time.Time, time.Time) {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch b.Period {
+	case BudgetPeriodWeekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		start := time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 7)
+	case BudgetPeriodYearly:
+		start := time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0)
+	case BudgetPeriodCustom:
+		end := b.StartDate.AddDate(0, 0, 1)
+		if b.EndDate != nil {
+			end = b.EndDate.AddDate(0, 0, 1)
+		}
+		return b.StartDate, end
+	default:
+		start := time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// Periods returns the budget periods overlapping [from, to), ignoring periods
+// that end before the budget starts.
+func (b Budget) Periods(from, to time.Time) [][2]time.Time {
+	var periods [][2]time.Time
+	if b.Period == BudgetPeriodCustom {
+		start, end := b.PeriodAt(b.StartDate)
+		if start.Before(to) && end.After(from) {
+			periods = append(periods, [2]time.Time{start, end})
+		}
+		return periods
+	}
+	if from.Before(b.StartDate) {
+		from = b.StartDate
+	}
+	start, _ := b.PeriodAt(from)
+	for start.Before(to) {
+		_, end := b.PeriodAt(start)
+		periods = append(periods, [2]time.Time{start, end})
+		start = end
+	}
+	return periods
+}
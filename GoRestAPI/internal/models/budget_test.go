@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestBudgetPeriodAt(t *testing.T) {
+	cases := []struct {
+		period     string
+		at         string
+		start, end string
+	}{
+		{BudgetPeriodMonthly, "2025-07-19", "2025-07-01", "2025-08-01"},
+		{BudgetPeriodWeekly, "2025-07-19", "2025-07-14", "2025-07-21"},
+		{BudgetPeriodWeekly, "2025-07-14", "2025-07-14", "2025-07-21"},
+		{BudgetPeriodYearly, "2025-07-19", "2025-01-01", "2026-01-01"},
+	}
+	for _, tc := range cases {
+		start, end := Budget{Period: tc.period}.PeriodAt(date(tc.at))
+		assert.Equal(t, date(tc.start), start, tc.period)
+		assert.Equal(t, date(tc.end), end, tc.period)
+	}
+}
+
+func TestBudgetPeriods(t *testing.T) {
+	b := Budget{Period: BudgetPeriodMonthly, StartDate: date("2025-05-01")}
+	periods := b.Periods(date("2025-04-01"), date("2025-07-15"))
+	assert.Len(t, periods, 3)
+	assert.Equal(t, date("2025-05-01"), periods[0][0])
+	assert.Equal(t, date("2025-08-01"), periods[2][1])
+
+	end := date("2025-06-30")
+	custom := Budget{Period: BudgetPeriodCustom, StartDate: date("2025-06-01"), EndDate: &end}
+	assert.Len(t, custom.Periods(date("2025-06-15"), date("2025-07-01")), 1)
+	assert.Empty(t, custom.Periods(date("2025-07-01"), date("2025-08-01")))
+}
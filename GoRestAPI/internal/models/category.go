@@ -1,8 +1,41 @@
-
 package models
 
+import (
+	"gorm.io/gorm"
+)
+
+// Category kinds. The kind decides which sign a transaction amount may have.
+const (
+	CategoryKindIncome   = "income"
+	CategoryKindExpense  = "expense"
+	CategoryKindTransfer = "transfer"
+)
+
 type Category struct {
-	ID     uint   `gorm:"primaryKey" json:"id"`
-	Name   string `gorm:"not null" json:"name"`
-	UserID uint   `gorm:"not null" json:"user_id"`
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"not null" json:"name"`
+	UserID    uint   `gorm:"not null" json:"user_id"`
+	ParentID  *uint  `json:"parent_id"`
+	Kind      string `json:"kind"`
+	Color     string `json:"color"`
+	Icon      string `json:"icon"`
+	Archived  bool   `gorm:"not null;default:false" json:"archived"`
+	SortOrder int    `gorm:"not null;default:0" json:"sort_order"`
+	// Version increases with every update; it is the ETag of the category
+	Version uint `gorm:"not null;default:1" json:"version"`
+	// DeletedAt is set while the category is in the trash
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// AllowsAmount reports whether a transaction amount has a sign compatible with the category kind.
+// Income categories take positive amounts, expense categories negative ones, transfers either.
+func (c Category) AllowsAmount(amount float64) bool {
+	switch c.Kind {
+	case CategoryKindIncome:
+		return amount > 0
+	case CategoryKindExpense, "":
+		return amount < 0
+	default:
+		return true
+	}
 }
@@ -0,0 +1,11 @@
+package models
+
+// CategoryTemplate is a category set imported by an admin. It overrides the
+// built-in template with the same key and locale.
+type CategoryTemplate struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Key        string `gorm:"not null;uniqueIndex:idx_category_templates_key_locale" json:"key"`
+	Locale     string `gorm:"not null;uniqueIndex:idx_category_templates_key_locale" json:"locale"`
+	Name       string `gorm:"not null" json:"name"`
+	Categories string `gorm:"not null" json:"-"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryAllowsAmount(t *testing.T) {
+	cases := []struct {
+		kind               string
+		negative, positive bool
+	}{
+		{CategoryKindIncome, false, true},
+		{CategoryKindExpense, true, false},
+		{"", true, false},
+		{CategoryKindTransfer, true, true},
+	}
+	for _, tc := range cases {
+		cat := Category{Kind: tc.kind}
+		assert.Equal(t, tc.negative, cat.AllowsAmount(-10), tc.kind)
+		assert.Equal(t, tc.positive, cat.AllowsAmount(10), tc.kind)
+		assert.False(t, cat.AllowsAmount(0) && tc.kind != CategoryKindTransfer, tc.kind)
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Digest frequencies
+const (
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+)
+
+// DigestSections are the parts a digest email can contain, in display order
+var DigestSections = []string{"summary", "categories", "budgets", "transactions"}
+
+// DigestSubscription schedules a spending digest email. Weekly digests are sent
+// on Weekday and cover the previous seven days; monthly digests are sent on the
+// first day of the month and cover the previous month. Both go out at TimeOfDay
+// (HH:MM) in Timezone.
+type DigestSubscription struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Frequency  string     `gorm:"not null" json:"frequency"`
+	Weekday    int        `gorm:"not null;default:1" json:"weekday"`
+	TimeOfDay  string     `gorm:"not null" json:"time_of_day"`
+	Timezone   string     `gorm:"not null" json:"timezone"`
+	Sections   string     `gorm:"not null" json:"-"`
+	Active     bool       `gorm:"not null;default:true" json:"active"`
+	LastSentAt *time.Time `json:"last_sent_at"`
+	NextRunAt  time.Time  `gorm:"not null;index" json:"next_run_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// SectionList returns the sections included in the digest
+func (d DigestSubscription) SectionList() []string {
+	if d.Sections == "" {
+		return nil
+	}
+	return strings.Split(d.Sections, ",")
+}
+
+func (d DigestSubscription) location() *time.Location {
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// NextRun returns the first send time strictly after the given time
+func (d DigestSubscription) NextRun(after time.Time) time.Time {
+	loc := d.location()
+	clock, err := time.Parse("15:04", d.TimeOfDay)
+	if err != nil {
+		clock = time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)
+	}
+	local := after.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if d.Frequency == DigestMonthly {
+		day = time.Date(local.Year(), local.Month(), 1, clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !day.After(after) {
+			day = day.AddDate(0, 1, 0)
+		}
+		return day
+	}
+	day = day.AddDate(0, 0, (d.Weekday-int(day.Weekday())+7)%7)
+	if !day.After(after) {
+		day = day.AddDate(0, 0, 7)
+	}
+	return day
+}
+
+// Period returns the calendar days [from, to) covered by a digest sent at the given time
+func (d DigestSubscription) Period(sentAt time.Time) (time.Time, time.Time) {
+	y, m, day := sentAt.In(d.location()).Date()
+	to := time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+	if d.Frequency == DigestMonthly {
+		to = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+		return to.AddDate(0, -1, 0), to
+	}
+	return to.AddDate(0, 0, -7), to
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestNextRun(t *testing.T) {
+	weekly := DigestSubscription{Frequency: DigestWeekly, Weekday: int(time.Monday), TimeOfDay: "08:30", Timezone: "Europe/Madrid"}
+	// Wednesday 2025-07-16 -> Monday 2025-07-21 08:30 CEST
+	next := weekly.NextRun(time.Date(2025, 7, 16, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 7, 21, 6, 30, 0, 0, time.UTC), next.UTC())
+	// exactly at the send time -> the following week
+	assert.Equal(t, next.AddDate(0, 0, 7), weekly.NextRun(next))
+
+	monthly := DigestSubscription{Frequency: DigestMonthly, TimeOfDay: "07:00", Timezone: "UTC"}
+	assert.Equal(t, time.Date(2025, 8, 1, 7, 0, 0, 0, time.UTC), monthly.NextRun(time.Date(2025, 7, 1, 7, 0, 0, 0, time.UTC)).UTC())
+	assert.Equal(t, time.Date(2025, 7, 1, 7, 0, 0, 0, time.UTC), monthly.NextRun(time.Date(2025, 7, 1, 6, 59, 0, 0, time.UTC)).UTC())
+}
+
+func TestDigestPeriod(t *testing.T) {
+	weekly := DigestSubscription{Frequency: DigestWeekly, Timezone: "America/New_York"}
+	from, to := weekly.Period(time.Date(2025, 7, 21, 2, 0, 0, 0, time.UTC))
+	assert.Equal(t, date("2025-07-13"), from)
+	assert.Equal(t, date("2025-07-20"), to)
+
+	monthly := DigestSubscription{Frequency: DigestMonthly, Timezone: "UTC"}
+	from, to = monthly.Period(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, date("2025-02-01"), from)
+	assert.Equal(t, date("2025-03-01"), to)
+}
@@ -0,0 +1,10 @@
+package models
+
+// EnvelopeAssignment is the money assigned to an envelope (an expense category) for a month.
+type EnvelopeAssignment struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	UserID     uint    `gorm:"not null;uniqueIndex:idx_envelope_assignments_month" json:"user_id"`
+	CategoryID uint    `gorm:"not null;uniqueIndex:idx_envelope_assignments_month" json:"category_id"`
+	Month      string  `gorm:"not null;uniqueIndex:idx_envelope_assignments_month" json:"month"`
+	Amount     float64 `gorm:"not null" json:"amount"`
+}
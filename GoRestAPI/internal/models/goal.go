@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// Goal is a savings target funded either by an account (its balance counts
+// toward the goal) or by a category of contribution transactions.
+type Goal struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null" json:"user_id"`
+	Name         string     `gorm:"not null" json:"name"`
+	TargetAmount float64    `gorm:"not null" json:"target_amount"`
+	TargetDate   *time.Time `json:"target_date"`
+	AccountID    *uint      `json:"account_id"`
+	CategoryID   *uint      `json:"category_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey stores the response to a POST request sent with an
+// Idempotency-Key header, so that a retry replays it instead of running the
+// request again. Status is 0 while the first request is still running.
+type IdempotencyKey struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key    string `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	// Fingerprint is a hash of the method, path with query string and body of the request
+	Fingerprint string `gorm:"not null"`
+	Status      int    `gorm:"not null;default:0"`
+	ContentType string
+	// Headers holds the replayed response headers other than Content-Type as a JSON object
+	Headers   string
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification is an entry in a user's in-app inbox.
+type Notification struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	UserID    uint            `gorm:"not null;index" json:"user_id"`
+	Kind      string          `gorm:"not null" json:"kind"`
+	Title     string          `gorm:"not null" json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data"`
+	Read      bool            `gorm:"not null;default:false" json:"read"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Webhook is an outgoing HTTP endpoint that receives a user's notifications.
+// The secret signing the deliveries is only shown when the webhook is created.
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"-"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BudgetAlert records that a budget threshold fired for a period, so it fires only once.
+type BudgetAlert struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	BudgetID    uint      `gorm:"not null;uniqueIndex:idx_budget_alerts_period" json:"budget_id"`
+	Threshold   int       `gorm:"not null;uniqueIndex:idx_budget_alerts_period" json:"threshold"`
+	PeriodStart time.Time `gorm:"not null;uniqueIndex:idx_budget_alerts_period" json:"period_start"`
+	Percentage  float64   `gorm:"not null" json:"percentage"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AnomalyAlert records that an anomaly was pushed to a user, so it is pushed only once.
+type AnomalyAlert struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_anomaly_alerts_key" json:"user_id"`
+	Key       string    `gorm:"not null;uniqueIndex:idx_anomaly_alerts_key" json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
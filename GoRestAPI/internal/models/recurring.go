@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+)
+
+// Recurring frequencies
+const (
+	FrequencyWeekly    = "weekly"
+	FrequencyBiweekly  = "biweekly"
+	FrequencyMonthly   = "monthly"
+	FrequencyQuarterly = "quarterly"
+	FrequencyYearly    = "yearly"
+)
+
+// RecurringTransaction is a template for a transaction that repeats on a fixed
+// schedule starting at StartDate, such as rent, salary or a subscription.
+type RecurringTransaction struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null" json:"user_id"`
+	CategoryID  uint       `gorm:"not null" json:"category_id"`
+	AccountID   *uint      `json:"account_id"`
+	Amount      float64    `gorm:"not null" json:"amount"`
+	Payee       string     `json:"payee"`
+	Description string     `json:"description"`
+	Frequency   string     `gorm:"not null" json:"frequency"`
+	StartDate   time.Time  `gorm:"not null" json:"start_date"`
+	EndDate     *time.Time `json:"end_date"`
+	Active      bool       `gorm:"not null;default:true" json:"active"`
+}
+
+// occurrence returns the n-th scheduled date. Monthly schedules keep the day of
+// the month of StartDate, clamped to the last day of shorter months.
+func (r RecurringTransaction) occurrence(n int) time.Time {
+	switch r.Frequency {
+	case FrequencyWeekly:
+		return r.StartDate.AddDate(0, 0, 7*n)
+	case FrequencyBiweekly:
+		return r.StartDate.AddDate(0, 0, 14*n)
+	}
+	months := n
+	switch r.Frequency {
+	case FrequencyQuarterly:
+		months = 3 * n
+	case FrequencyYearly:
+		months = 12 * n
+	}
+	y, m, d := r.StartDate.Date()
+	first := time.Date(y, m+time.Month(months), 1, 0, 0, 0, 0, r.StartDate.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, r.StartDate.Location())
+}
+
+// Occurrences returns the scheduled dates within [from, to).
+func (r RecurringTransaction) Occurrences(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for n := 0; ; n++ {
+		date := r.occurrence(n)
+		if !date.Before(to) || (r.EndDate != nil && date.After(*r.EndDate)) {
+			return dates
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+}
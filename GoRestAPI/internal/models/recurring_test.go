@@ -0,0 +1,24 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurringOccurrences(t *testing.T) {
+	monthly := RecurringTransaction{Frequency: FrequencyMonthly, StartDate: date("2025-01-31")}
+	dates := monthly.Occurrences(date("2025-01-01"), date("2025-05-01"))
+	var got []string
+	for _, d := range dates {
+		got = append(got, d.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"}, got)
+
+	end := date("2025-07-20")
+	weekly := RecurringTransaction{Frequency: FrequencyWeekly, StartDate: date("2025-07-01"), EndDate: &end}
+	assert.Len(t, weekly.Occurrences(date("2025-07-05"), date("2025-08-01")), 2)
+
+	yearly := RecurringTransaction{Frequency: FrequencyYearly, StartDate: date("2024-02-29")}
+	assert.Equal(t, date("2025-02-28"), yearly.Occurrences(date("2025-01-01"), date("2026-01-01"))[0])
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// MonthlyRollup holds the totals of a user's transactions in one category over
+// one calendar month. Month is the first day of the month at UTC midnight.
+type MonthlyRollup struct {
+	UserID     uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Month      time.Time `gorm:"primaryKey" json:"month"`
+	CategoryID uint      `gorm:"primaryKey;autoIncrement:false" json:"category_id"`
+	Income     float64   `gorm:"not null" json:"income"`
+	Expense    float64   `gorm:"not null" json:"expense"`
+	Count      int       `gorm:"not null" json:"count"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// Tag is a free-form label a user can attach to any number of transactions
+type Tag struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"user_id"`
+	Name   string `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"name"`
+}
+
+// TransactionTag links a transaction to a tag
+type TransactionTag struct {
+	TransactionID uint `gorm:"primaryKey"`
+	TagID         uint `gorm:"primaryKey;index"`
+}
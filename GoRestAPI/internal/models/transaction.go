@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Transaction struct {
@@ -9,6 +11,17 @@ type Transaction struct {
 	Amount      float64   `gorm:"not null" json:"amount"`
 	Date        time.Time `gorm:"not null" json:"date"`
 	CategoryID  uint      `gorm:"not null" json:"category_id"`
+	AccountID   *uint     `json:"account_id"`
 	UserID      uint      `gorm:"not null" json:"user_id"`
+	Payee       string    `json:"payee"`
 	Description string    `json:"description"`
+	Notes       string    `json:"notes"`
+	// Version increases with every update; it is the ETag of the transaction
+	Version uint `gorm:"not null;default:1" json:"version"`
+	// DeletedAt is set while the transaction is in the trash
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	// Tags are the names of the transaction's tags, stored in transaction_tags
+	Tags []string `gorm:"-" json:"tags"`
+	// Snippet is the highlighted match of a full-text search
+	Snippet string `gorm:"-" json:"snippet,omitempty"`
 }
@@ -1,10 +1,20 @@
-
 package models
 
+// Budgeting modes. Envelope mode enables zero-based budgeting where income is
+// assigned to categories month by month.
+const (
+	BudgetModeStandard = "standard"
+	BudgetModeEnvelope = "envelope"
+)
+
 type User struct {
-	ID           uint   `gorm:"primaryKey" json:"id"`
-	Email        string `gorm:"unique;not null" json:"email"`
-	PasswordHash string `gorm:"not null" json:"-"`
-	Categories   []Category
-	Transactions []Transaction
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	Email         string `gorm:"unique;not null" json:"email"`
+	PasswordHash  string `gorm:"not null" json:"-"`
+	IsAdmin       bool   `gorm:"not null;default:false" json:"is_admin"`
+	BudgetMode    string `json:"budget_mode"`
+	Timezone      string `json:"timezone"`
+	AnomalyAlerts bool   `gorm:"not null;default:false" json:"anomaly_alerts"`
+	Categories    []Category
+	Transactions  []Transaction
 }
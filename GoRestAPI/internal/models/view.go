@@ -0,0 +1,12 @@
+package models
+
+// SavedView is a named transaction filter ("smart view") a user can reuse
+type SavedView struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_saved_views_user_name" json:"user_id"`
+	Name   string `gorm:"not null;uniqueIndex:idx_saved_views_user_name" json:"name"`
+	// Filter is an expression in the filter language of GET /transactions?filter=
+	Filter string `gorm:"not null" json:"filter"`
+	// Sort is the default sort of the view, e.g. "-amount"
+	Sort string `json:"sort"`
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+	"expense-tracker/internal/config"
+)
+
+// Email is a message with a plain-text body and an optional HTML alternative.
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers emails. Implementations are selected with the MAILER env variable.
+type Mailer interface {
+	Send(email Email) error
+}
+
+// LogMailer writes emails to the server log instead of sending them.
+type LogMailer struct{}
+
+func (LogMailer) Send(email Email) error {
+	log.Printf("[mail] to=%s subject=%q\n%s", email.To, email.Subject, email.Text)
+	return nil
+}
+
+// SMTPMailer sends emails through an SMTP server using PLAIN auth.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(email Email) error {
+	msg, err := buildMessage(m.From, email)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Port), auth, m.From, []string{email.To}, msg)
+}
+
+// FileMailer drops each email as an .eml file into Dir, for local testing.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+var fileMailerSeq uint64
+
+func (m FileMailer) Send(email Email) error {
+	msg, err := buildMessage(m.From, email)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%d.eml", time.Now().UTC().Format("20060102T150405.000000000"), atomic.AddUint64(&fileMailerSeq, 1))
+	return os.WriteFile(filepath.Join(m.Dir, name), msg, 0o644)
+}
+
+// NewMailer builds the mailer selected in the configuration
+func NewMailer(cfg config.MailConfig) Mailer {
+	switch cfg.Mailer {
+	case "smtp":
+		return SMTPMailer{Host: cfg.SMTPHost, Port: cfg.SMTPPort, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, From: cfg.From}
+	case "file":
+		return FileMailer{Dir: cfg.Dir, From: cfg.From}
+	default:
+		return LogMailer{}
+	}
+}
+
+// buildMessage renders an RFC 5322 message, as multipart/alternative when an HTML body is present
+func buildMessage(from string, email Email) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", from, email.To, email.Subject)
+	if email.HTML == "" {
+		fmt.Fprintf(&buf, "Content-Type: text/plain; charset=UTF-8\r\n\r\n%s", email.Text)
+		return buf.Bytes(), nil
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		pw.Write([]byte(part.content))
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliverWebhookSignsBody(t *testing.T) {
+	var gotSignature, gotBody string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotSignature = r.Header.Get("X-Webhook-Signature")
+	}))
+	defer server.Close()
+	// the test server listens on loopback, which the real client refuses to dial
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = server.Client()
+
+	hook := models.Webhook{ID: 1, URL: server.URL, Secret: "s3cret"}
+	err := deliverWebhook(hook, WebhookPayload{Event: "budget.threshold", Notification: models.Notification{Title: "Groceries budget at 80%"}})
+	assert.NoError(t, err)
+	assert.Contains(t, gotBody, "Groceries budget at 80%")
+	assert.Equal(t, Sign("s3cret", []byte(gotBody)), gotSignature)
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	assert.NoError(t, ValidateWebhookURL("https://example.com/hook"))
+	assert.NoError(t, ValidateWebhookURL("https://93.184.216.34:8443/hook"))
+	for _, raw := range []string{
+		"http://example.com/hook",
+		"ftp://example.com/hook",
+		"https:///hook",
+		"https://localhost/hook",
+		"https://api.localhost./hook",
+		"https://127.0.0.1/hook",
+		"https://[::1]/hook",
+		"https://10.1.2.3/hook",
+		"https://192.168.0.10/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[fe80::1]/hook",
+		"https://100.64.0.1/hook",
+		"https://0.0.0.0/hook",
+		"https://[::ffff:127.0.0.1]/hook",
+	} {
+		assert.Error(t, ValidateWebhookURL(raw), raw)
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	var hits int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	// a public name that resolves to loopback only fails at dial time
+	_, err := newWebhookClient().Post(server.URL, "application/json", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, ErrWebhookAddress)
+	assert.Zero(t, hits)
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	var redirected bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected = true
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = server.Client()
+	httpClient.CheckRedirect = newWebhookClient().CheckRedirect
+
+	err := deliverWebhook(models.Webhook{URL: server.URL, Secret: "s"}, WebhookPayload{Event: "test"})
+	assert.ErrorContains(t, err, "307")
+	assert.False(t, redirected)
+}
+
+func TestBuildMessageMultipart(t *testing.T) {
+	msg, err := buildMessage("from@example.com", Email{To: "to@example.com", Subject: "Digest", Text: "plain", HTML: "<p>html</p>"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "multipart/alternative")
+	assert.True(t, strings.Contains(string(msg), "plain") && strings.Contains(string(msg), "<p>html</p>"))
+}
+
+func TestFileMailerDropsEml(t *testing.T) {
+	dir := t.TempDir()
+	m := FileMailer{Dir: filepath.Join(dir, "out"), From: "from@example.com"}
+	assert.NoError(t, m.Send(Email{To: "a@example.com", Subject: "One", Text: "1"}))
+	assert.NoError(t, m.Send(Email{To: "b@example.com", Subject: "Two", Text: "2"}))
+	files, _ := filepath.Glob(filepath.Join(dir, "out", "*.eml"))
+	assert.Len(t, files, 2)
+	content, _ := os.ReadFile(files[0])
+	assert.Contains(t, string(content), "Subject: One")
+}
@@ -0,0 +1,46 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTo(t *testing.T) {
+	d := New()
+	d.Text(40, 800, 12, true, "Statement (July)")
+	d.AddPage()
+	d.Line(40, 700, 500, 700, 0.5, 0)
+	d.SetPage(0)
+	d.Text(40, 30, 8, false, "Página 1")
+
+	var buf bytes.Buffer
+	_, err := d.WriteTo(&buf)
+	assert.NoError(t, err)
+	out := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4")))
+	assert.Contains(t, string(out), "/Count 2")
+
+	// every xref entry points at the start of its object
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out[xref:], -1)
+	assert.Len(t, entries, 8)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		assert.True(t, bytes.HasPrefix(out[off:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+	}
+}
+
+func TestTextWidthAndTruncate(t *testing.T) {
+	assert.InDelta(t, 5.56, TextWidth("0", 10, false), 1e-9)
+	assert.Greater(t, TextWidth("Total", 10, true), TextWidth("Total", 10, false))
+	s := Truncate("A very long description of a purchase", 60, 9, false)
+	assert.LessOrEqual(t, TextWidth(s, 9, false), 60.0)
+	assert.Equal(t, "...", s[len(s)-3:])
+	assert.Equal(t, `a\(b\)\\`, escape(`a(b)\`))
+}
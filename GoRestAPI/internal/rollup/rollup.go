@@ -0,0 +1,95 @@
+// Package rollup maintains the monthly_rollups table: per-user, per-month,
+// per-category income, expense and transaction counts. Every write to
+// transactions applies its delta in the same database transaction, so reports
+// over whole months can read the rollups instead of scanning transactions.
+// Rebuild recomputes them from scratch if they ever drift.
+package rollup
+
+import (
+	"time"
+
+	"expense-tracker/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Month returns the first day of the month containing d, at UTC midnight
+func Month(d time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Aligned reports whether [from, to) covers whole months only
+func Aligned(from, to time.Time) bool {
+	return Month(from).Equal(from) && Month(to).Equal(to)
+}
+
+// delta is the change a transaction makes to its rollup row, negated when it is removed
+func delta(tx models.Transaction, sign float64) models.MonthlyRollup {
+	r := models.MonthlyRollup{UserID: tx.UserID, Month: Month(tx.Date), CategoryID: tx.CategoryID, Count: int(sign)}
+	if tx.Amount > 0 {
+		r.Income = sign * tx.Amount
+	} else {
+		r.Expense = sign * tx.Amount
+	}
+	return r
+}
+
+func apply(db *gorm.DB, r models.MonthlyRollup) error {
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "month"}, {Name: "category_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"income":  gorm.Expr("monthly_rollups.income + excluded.income"),
+			"expense": gorm.Expr("monthly_rollups.expense + excluded.expense"),
+			"count":   gorm.Expr("monthly_rollups.count + excluded.count"),
+		}),
+	}).Create(&r).Error
+	if err != nil || r.Count > 0 {
+		return err
+	}
+	// drop rows whose last transaction went away rather than keep rounding residue
+	return db.Where("user_id = ? AND month = ? AND category_id = ? AND count <= 0", r.UserID, r.Month, r.CategoryID).
+		Delete(&models.MonthlyRollup{}).Error
+}
+
+// Add counts a new transaction in its rollup row
+func Add(db *gorm.DB, tx models.Transaction) error {
+	return apply(db, delta(tx, 1))
+}
+
+// Remove takes a deleted transaction out of its rollup row
+func Remove(db *gorm.DB, tx models.Transaction) error {
+	return apply(db, delta(tx, -1))
+}
+
+// Move replaces the old version of an updated transaction with the new one
+func Move(db *gorm.DB, before, after models.Transaction) error {
+	if err := Remove(db, before); err != nil {
+		return err
+	}
+	return Add(db, after)
+}
+
+// Rebuild recomputes the rollups of a user from their transactions, or of every user when userID is 0
+func Rebuild(db *gorm.DB, userID uint) error {
+	return db.Transaction(func(db *gorm.DB) error {
+		del := db.Where("1 = 1")
+		if userID != 0 {
+			del = db.Where("user_id = ?", userID)
+		}
+		if err := del.Delete(&models.MonthlyRollup{}).Error; err != nil {
+			return err
+		}
+		query := `INSERT INTO monthly_rollups (user_id, month, category_id, income, expense, count)
+			SELECT user_id, strftime('%Y-%m-01 00:00:00+00:00', date), category_id,
+				SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END),
+				SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END),
+				COUNT(*)
+			FROM transactions WHERE deleted_at IS NULL`
+		var args []interface{}
+		if userID != 0 {
+			query += " AND user_id = ?"
+			args = append(args, userID)
+		}
+		return db.Exec(query+" GROUP BY 1, 2, 3", args...).Error
+	})
+}
@@ -0,0 +1,36 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestAligned(t *testing.T) {
+	assert.Equal(t, date("2025-07-01"), Month(date("2025-07-19")))
+	assert.True(t, Aligned(date("2025-01-01"), date("2025-04-01")))
+	assert.False(t, Aligned(date("2025-01-01"), date("2025-03-31")))
+	assert.False(t, Aligned(date("2025-01-02"), date("2025-04-01")))
+}
+
+func TestDelta(t *testing.T) {
+	tx := models.Transaction{UserID: 1, CategoryID: 5, Amount: -42.5, Date: date("2025-07-19")}
+	r := delta(tx, 1)
+	assert.Equal(t, date("2025-07-01"), r.Month)
+	assert.Equal(t, -42.5, r.Expense)
+	assert.Zero(t, r.Income)
+	assert.Equal(t, 1, r.Count)
+
+	tx.Amount = 10
+	r = delta(tx, -1)
+	assert.Equal(t, -10.0, r.Income)
+	assert.Zero(t, r.Expense)
+	assert.Equal(t, -1, r.Count)
+}
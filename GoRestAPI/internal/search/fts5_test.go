@@ -0,0 +1,6 @@
+//go:build sqlite_fts5
+
+package search
+
+// ftsBuild is whether the tests run against SQLite built with FTS5
+const ftsBuild = true
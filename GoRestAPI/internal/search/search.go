@@ -0,0 +1,240 @@
+// Package search implements full-text search over transactions. When SQLite
+// is built with FTS5 (go build -tags sqlite_fts5) the description, payee,
+// notes, tags and category name of every transaction are kept in the
+// transactions_fts index, which gives prefix matching, bm25 ranking and
+// snippets. Without FTS5 the same query falls back to LIKE matching ordered by
+// date, with snippets highlighted in Go.
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Marks wrap the matched words of a snippet
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// delimiters passed to snippet(), which does not escape the text around them;
+// they become marks once the snippet is escaped
+const (
+	ftsMarkStart = "\x02"
+	ftsMarkEnd   = "\x03"
+)
+
+// snippetWords is how many words a snippet shows around the first match
+const snippetWords = 10
+
+// maxTerms bounds how many words of a query are searched for
+const maxTerms = 8
+
+var ftsEnabled bool
+
+// Enabled reports whether the FTS5 index is in use
+func Enabled() bool {
+	return ftsEnabled
+}
+
+// indexed is the text of a transaction as stored in the index, in column order.
+// Trashed transactions are not indexed.
+const indexed = `SELECT t.id, COALESCE(t.description, ''), COALESCE(t.payee, ''), COALESCE(t.notes, ''),
+	COALESCE((SELECT group_concat(tg.name, ' ') FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.transaction_id = t.id), ''),
+	COALESCE(c.name, '')
+	FROM transactions t LEFT JOIN categories c ON c.id = t.category_id
+	WHERE t.deleted_at IS NULL`
+
+// Setup creates the FTS5 index when SQLite supports it and fills it if it is
+// missing rows. Otherwise searches use the LIKE fallback.
+func Setup(db *gorm.DB) {
+	// probe quietly: a missing fts5 module is expected in default builds
+	quiet := db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)})
+	err := quiet.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS transactions_fts USING fts5(
+		description, payee, notes, tags, category, tokenize = 'unicode61 remove_diacritics 2')`).Error
+	if err != nil {
+		log.Printf("full-text search index unavailable, falling back to LIKE: %v", err)
+		return
+	}
+	ftsEnabled = true
+	var indexedRows, rows int64
+	db.Table("transactions_fts").Count(&indexedRows)
+	db.Table("transactions").Where("deleted_at IS NULL").Count(&rows)
+	if indexedRows != rows {
+		if err := Rebuild(db); err != nil {
+			log.Printf("failed to rebuild search index: %v", err)
+		}
+	}
+}
+
+// Rebuild reindexes every transaction
+func Rebuild(db *gorm.DB) error {
+	if !ftsEnabled {
+		return nil
+	}
+	return db.Transaction(func(db *gorm.DB) error {
+		if err := db.Exec("DELETE FROM transactions_fts").Error; err != nil {
+			return err
+		}
+		return db.Exec("INSERT INTO transactions_fts (rowid, description, payee, notes, tags, category) " + indexed).Error
+	})
+}
+
+// Index refreshes the index entries of the given transactions; call it after
+// any change to a transaction, its tags or its category
+func Index(db *gorm.DB, ids ...uint) error {
+	if !ftsEnabled || len(ids) == 0 {
+		return nil
+	}
+	if err := Remove(db, ids...); err != nil {
+		return err
+	}
+	return db.Exec("INSERT INTO transactions_fts (rowid, description, payee, notes, tags, category) "+indexed+" AND t.id IN ?", ids).Error
+}
+
+// Remove drops deleted or trashed transactions from the index
+func Remove(db *gorm.DB, ids ...uint) error {
+	if !ftsEnabled || len(ids) == 0 {
+		return nil
+	}
+	return db.Exec("DELETE FROM transactions_fts WHERE rowid IN ?", ids).Error
+}
+
+// Terms splits a query into lowercase words, ignoring punctuation and FTS syntax
+func Terms(q string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+	return terms
+}
+
+// matchExpr builds an FTS5 query matching rows that contain every term as a word prefix
+func matchExpr(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = fmt.Sprintf(`"%s"*`, t)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Filter restricts a query on the transactions table to rows matching every
+// term. Terms must come from Terms.
+func Filter(query *gorm.DB, terms []string) *gorm.DB {
+	if ftsEnabled {
+		return query.Joins("JOIN transactions_fts ON transactions_fts.rowid = transactions.id").
+			Where("transactions_fts MATCH ?", matchExpr(terms))
+	}
+	for _, t := range terms {
+		like := "%" + t + "%"
+		query = query.Where(`(transactions.description LIKE ? OR transactions.payee LIKE ? OR transactions.notes LIKE ?
+			OR transactions.category_id IN (SELECT id FROM categories WHERE name LIKE ?)
+			OR transactions.id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tg.name LIKE ?))`,
+			like, like, like, like, like)
+	}
+	return query
+}
+
+// Ranked orders a filtered query by relevance, best first. Without FTS5 there
+// is no relevance score and the order is left to the caller.
+func Ranked(query *gorm.DB) *gorm.DB {
+	if !ftsEnabled {
+		return query
+	}
+	// payee and tags weigh more than free text
+	return query.Order("bm25(transactions_fts, 1.0, 2.0, 1.0, 2.0, 1.0)")
+}
+
+// Snippets returns a highlighted excerpt of the best matching field of each
+// transaction, keyed by transaction ID. Snippets are HTML: the text is escaped
+// and only the marks are markup.
+func Snippets(db *gorm.DB, terms []string, ids []uint) (map[uint]string, error) {
+	snippets := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return snippets, nil
+	}
+	var rows *sql.Rows
+	var err error
+	if ftsEnabled {
+		rows, err = db.Raw("SELECT rowid, snippet(transactions_fts, -1, ?, ?, '...', ?) FROM transactions_fts WHERE transactions_fts MATCH ? AND rowid IN ?",
+			ftsMarkStart, ftsMarkEnd, snippetWords, matchExpr(terms), ids).Rows()
+	} else {
+		rows, err = db.Raw(indexed+" AND t.id IN ?", ids).Rows()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint
+		if ftsEnabled {
+			var snippet string
+			if err := rows.Scan(&id, &snippet); err != nil {
+				return nil, err
+			}
+			snippets[id] = escapeSnippet(snippet)
+			continue
+		}
+		fields := make([]string, 5)
+		if err := rows.Scan(&id, &fields[0], &fields[1], &fields[2], &fields[3], &fields[4]); err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if s, ok := Highlight(f, terms); ok {
+				snippets[id] = s
+				break
+			}
+		}
+	}
+	return snippets, rows.Err()
+}
+
+// escapeSnippet HTML-escapes an FTS snippet and turns its delimiters into marks
+func escapeSnippet(s string) string {
+	return strings.NewReplacer(ftsMarkStart, MarkStart, ftsMarkEnd, MarkEnd).Replace(html.EscapeString(s))
+}
+
+// Highlight HTML-escapes text, marks the words containing a term and cuts it
+// down to the words around the first match. The second result is false when no
+// word matches.
+func Highlight(text string, terms []string) (string, bool) {
+	words := strings.Fields(text)
+	first := -1
+	marked := make([]string, len(words))
+	for i, w := range words {
+		marked[i] = html.EscapeString(w)
+		lower := strings.ToLower(w)
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				marked[i] = MarkStart + marked[i] + MarkEnd
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+	from := max(first-snippetWords/3, 0)
+	to := min(from+snippetWords, len(words))
+	from = max(to-snippetWords, 0)
+	s := strings.Join(marked[from:to], " ")
+	if from > 0 {
+		s = "..." + s
+	}
+	if to < len(words) {
+		s += "..."
+	}
+	return s, true
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"expense-tracker/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"amazon", "order"}, Terms(`Amazon "order"*`))
+	assert.Equal(t, []string{"café", "n26", "or"}, Terms("Café, N26 -- OR"))
+	assert.Empty(t, Terms(` "*" () `))
+	assert.Len(t, Terms("a b c d e f g h i j"), maxTerms)
+}
+
+func TestMatchExpr(t *testing.T) {
+	assert.Equal(t, `"amazon"* "spr"*`, matchExpr([]string{"amazon", "spr"}))
+}
+
+func TestHighlight(t *testing.T) {
+	s, ok := Highlight("Amazon order: headphones", []string{"amaz"})
+	assert.True(t, ok)
+	assert.Equal(t, "<mark>Amazon</mark> order: headphones", s)
+
+	s, ok = Highlight("one two three four five six seven eight nine ten eleven twelve thirteen fourteen", []string{"nine"})
+	assert.True(t, ok)
+	assert.Equal(t, "...five six seven eight <mark>nine</mark> ten eleven twelve thirteen fourteen", s)
+
+	_, ok = Highlight("groceries", []string{"rent"})
+	assert.False(t, ok)
+
+	s, _ = Highlight(`<img src=x onerror="alert(1)"> rent`, []string{"rent", "img"})
+	assert.Equal(t, `<mark>&lt;img</mark> src=x onerror=&#34;alert(1)&#34;&gt; <mark>rent</mark>`, s)
+}
+
+func TestEscapeSnippet(t *testing.T) {
+	assert.Equal(t, "...a &lt;b&gt; <mark>Amazon</mark> &amp; co", escapeSnippet("...a <b> "+ftsMarkStart+"Amazon"+ftsMarkEnd+" & co"))
+}
+
+// TestSearch runs against whichever index the build has: go test covers the
+// LIKE fallback and go test -tags sqlite_fts5 the FTS5 index.
+func TestSearch(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "search.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&models.Category{}, &models.Transaction{}, &models.Tag{}, &models.TransactionTag{}))
+	cat := models.Category{UserID: 1, Name: "Electronics", Kind: models.CategoryKindExpense}
+	db.Create(&cat)
+	txs := []models.Transaction{
+		{UserID: 1, CategoryID: cat.ID, Amount: -60, Payee: "Amazon", Notes: "headphones <script>alert(1)</script>"},
+		{UserID: 1, CategoryID: cat.ID, Amount: -20, Payee: "Corner Market", Description: "cables"},
+		{UserID: 1, CategoryID: cat.ID, Amount: -5, Payee: "Amazon", Description: "trashed order"},
+	}
+	for i := range txs {
+		txs[i].Date = time.Date(2025, 7, i+1, 0, 0, 0, 0, time.UTC)
+		db.Create(&txs[i])
+	}
+	db.Delete(&txs[2])
+
+	Setup(db)
+	defer func() { ftsEnabled = false }()
+	assert.Equal(t, ftsBuild, Enabled())
+
+	find := func(q string) []uint {
+		var ids []uint
+		Ranked(Filter(db.Model(&models.Transaction{}), Terms(q))).Pluck("transactions.id", &ids)
+		return ids
+	}
+	assert.Equal(t, []uint{txs[0].ID}, find("amaz"), "trashed transactions are not found")
+	assert.Equal(t, []uint{txs[0].ID}, find("headphone amazon"))
+	assert.ElementsMatch(t, []uint{txs[0].ID, txs[1].ID}, find("electronics"))
+	assert.Empty(t, find("amazon cables"))
+
+	snippets, err := Snippets(db, Terms("headphones"), []uint{txs[0].ID})
+	assert.NoError(t, err)
+	assert.Contains(t, snippets[txs[0].ID], MarkStart+"headphones"+MarkEnd)
+	assert.Contains(t, snippets[txs[0].ID], "&lt;script&gt;")
+	assert.NotContains(t, snippets[txs[0].ID], "<script>")
+
+	// edits reach the index once the transaction is reindexed
+	db.Model(&txs[1]).Update("description", "usb charger")
+	assert.NoError(t, Index(db, txs[1].ID))
+	assert.Equal(t, []uint{txs[1].ID}, find("charger"))
+
+	// the transactions of a trashed category are still found by its name
+	db.Delete(&cat)
+	assert.NoError(t, Index(db, txs[0].ID, txs[1].ID))
+	assert.ElementsMatch(t, []uint{txs[0].ID, txs[1].ID}, find("electronics"))
+}
@@ -0,0 +1,224 @@
+// Package templates provides the category sets applied to new accounts.
+// Built-in templates are embedded JSON files, one per locale; admins can
+// import templates that override a built-in one with the same key and locale.
+package templates
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"expense-tracker/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultKey    = "default"
+	DefaultLocale = "en"
+)
+
+var ErrNotFound = errors.New("template not found")
+
+//go:embed data/*.json
+var builtinFS embed.FS
+
+var builtin []Template
+
+type Category struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Color string `json:"color,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+}
+
+type Template struct {
+	Key        string     `json:"key"`
+	Locale     string     `json:"locale"`
+	Name       string     `json:"name"`
+	Builtin    bool       `json:"builtin"`
+	Categories []Category `json:"categories"`
+}
+
+func init() {
+	files, err := builtinFS.ReadDir("data")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		content, err := builtinFS.ReadFile(path.Join("data", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var set []Template
+		if err := json.Unmarshal(content, &set); err != nil {
+			panic(fmt.Sprintf("invalid template file %s: %v", f.Name(), err))
+		}
+		locale := strings.TrimSuffix(f.Name(), ".json")
+		for _, t := range set {
+			t.Locale = locale
+			t.Builtin = true
+			if err := t.Validate(); err != nil {
+				panic(fmt.Sprintf("invalid template %s/%s: %v", locale, t.Key, err))
+			}
+			builtin = append(builtin, t)
+		}
+	}
+}
+
+// Builtin returns the templates embedded in the binary.
+func Builtin() []Template {
+	return append([]Template(nil), builtin...)
+}
+
+// Validate checks that a template has a key, a locale and well-formed categories.
+func (t Template) Validate() error {
+	if t.Key == "" || t.Locale == "" || t.Name == "" {
+		return errors.New("key, locale and name are required")
+	}
+	if len(t.Categories) == 0 {
+		return errors.New("template has no categories")
+	}
+	seen := make(map[string]bool)
+	for _, c := range t.Categories {
+		if c.Name == "" {
+			return errors.New("category name is required")
+		}
+		switch c.Kind {
+		case models.CategoryKindIncome, models.CategoryKindExpense, models.CategoryKindTransfer:
+		default:
+			return fmt.Errorf("category %q has invalid kind %q", c.Name, c.Kind)
+		}
+		if seen[strings.ToLower(c.Name)] {
+			return fmt.Errorf("duplicate category %q", c.Name)
+		}
+		seen[strings.ToLower(c.Name)] = true
+	}
+	return nil
+}
+
+// List returns the templates available for a locale (all locales when empty),
+// with imported templates taking precedence over built-in ones.
+func List(db *gorm.DB, locale string) ([]Template, error) {
+	byID := make(map[string]Template)
+	for _, t := range builtin {
+		if locale == "" || t.Locale == locale {
+			byID[t.Locale+"/"+t.Key] = t
+		}
+	}
+	var rows []models.CategoryTemplate
+	query := db
+	if locale != "" {
+		query = query.Where("locale = ?", locale)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		t, err := fromModel(row)
+		if err != nil {
+			return nil, err
+		}
+		byID[t.Locale+"/"+t.Key] = t
+	}
+	list := make([]Template, 0, len(byID))
+	for _, t := range byID {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Locale != list[j].Locale {
+			return list[i].Locale < list[j].Locale
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list, nil
+}
+
+// Find looks up a template by key and locale, falling back to DefaultLocale
+// when the requested locale has no template with that key.
+func Find(db *gorm.DB, key, locale string) (Template, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	for _, l := range []string{locale, DefaultLocale} {
+		var row models.CategoryTemplate
+		err := db.Where("key = ? AND locale = ?", key, l).First(&row).Error
+		if err == nil {
+			return fromModel(row)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return Template{}, err
+		}
+		for _, t := range builtin {
+			if t.Key == key && t.Locale == l {
+				return t, nil
+			}
+		}
+	}
+	return Template{}, ErrNotFound
+}
+
+// Save stores an imported template, replacing any previous import with the same key and locale.
+func Save(db *gorm.DB, t Template) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	categories, err := json.Marshal(t.Categories)
+	if err != nil {
+		return err
+	}
+	row := models.CategoryTemplate{Key: t.Key, Locale: t.Locale}
+	if err := db.Where(row).FirstOrInit(&row).Error; err != nil {
+		return err
+	}
+	row.Name = t.Name
+	row.Categories = string(categories)
+	return db.Save(&row).Error
+}
+
+// Apply creates the template categories for a user, skipping names the user
+// already has. It returns the categories that were created.
+func Apply(db *gorm.DB, userID uint, t Template) ([]models.Category, error) {
+	created := []models.Category{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.Category
+		if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+			return err
+		}
+		names := make(map[string]bool)
+		for _, c := range existing {
+			names[strings.ToLower(c.Name)] = true
+		}
+		for i, c := range t.Categories {
+			if names[strings.ToLower(c.Name)] {
+				continue
+			}
+			cat := models.Category{
+				Name:      c.Name,
+				UserID:    userID,
+				Kind:      c.Kind,
+				Color:     c.Color,
+				Icon:      c.Icon,
+				SortOrder: i,
+				Version:   1,
+			}
+			if err := tx.Create(&cat).Error; err != nil {
+				return err
+			}
+			created = append(created, cat)
+		}
+		return nil
+	})
+	return created, err
+}
+
+func fromModel(row models.CategoryTemplate) (Template, error) {
+	t := Template{Key: row.Key, Locale: row.Locale, Name: row.Name}
+	if err := json.Unmarshal([]byte(row.Categories), &t.Categories); err != nil {
+		return Template{}, fmt.Errorf("stored template %s/%s is corrupt: %w", row.Locale, row.Key, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,27 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinTemplates(t *testing.T) {
+	list := Builtin()
+	assert.NotEmpty(t, list)
+	locales := make(map[string]bool)
+	for _, tmpl := range list {
+		assert.NoError(t, tmpl.Validate(), "%s/%s", tmpl.Locale, tmpl.Key)
+		if tmpl.Key == DefaultKey {
+			locales[tmpl.Locale] = true
+		}
+	}
+	for _, locale := range []string{"en", "es", "fr"} {
+		assert.True(t, locales[locale], "missing default template for %s", locale)
+	}
+}
+
+func TestValidateRejectsBadKind(t *testing.T) {
+	tmpl := Template{Key: "x", Locale: "en", Name: "X", Categories: []Category{{Name: "Food", Kind: "spending"}}}
+	assert.Error(t, tmpl.Validate())
+}
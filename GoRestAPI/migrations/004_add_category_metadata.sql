@@ -0,0 +1,13 @@
+ALTER TABLE categories ADD COLUMN kind TEXT;
+UPDATE categories SET kind = 'expense';
+ALTER TABLE categories ADD COLUMN color TEXT;
+ALTER TABLE categories ADD COLUMN icon TEXT;
+ALTER TABLE categories ADD COLUMN archived BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE categories ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+UPDATE categories SET kind = 'income' WHERE id IN (
+    SELECT category_id FROM transactions GROUP BY category_id HAVING MIN(amount) > 0
+);
+-- categories with amounts of both signs become transfers, the only kind that accepts them all
+UPDATE categories SET kind = 'transfer' WHERE id IN (
+    SELECT category_id FROM transactions GROUP BY category_id HAVING MIN(amount) <= 0 AND MAX(amount) >= 0
+);
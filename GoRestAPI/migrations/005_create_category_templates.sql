@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS category_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key TEXT NOT NULL,
+    locale TEXT NOT NULL,
+    name TEXT NOT NULL,
+    categories TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_templates_key_locale ON category_templates(key, locale);
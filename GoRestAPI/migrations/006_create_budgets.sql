@@ -0,0 +1,13 @@
+ALTER TABLE categories ADD COLUMN parent_id INTEGER REFERENCES categories(id);
+CREATE TABLE IF NOT EXISTS budgets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    category_id INTEGER NOT NULL,
+    period TEXT NOT NULL,
+    amount REAL NOT NULL,
+    rollover BOOLEAN NOT NULL DEFAULT 0,
+    start_date DATETIME NOT NULL,
+    end_date DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(category_id) REFERENCES categories(id)
+);
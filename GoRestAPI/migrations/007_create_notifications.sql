@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT,
+    data BLOB,
+    read BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id);
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    budget_id INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    period_start DATETIME NOT NULL,
+    percentage REAL NOT NULL,
+    created_at DATETIME,
+    FOREIGN KEY(budget_id) REFERENCES budgets(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budget_alerts_period ON budget_alerts(budget_id, threshold, period_start);
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN budget_mode TEXT;
+CREATE TABLE IF NOT EXISTS envelope_assignments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    category_id INTEGER NOT NULL,
+    month TEXT NOT NULL,
+    amount REAL NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(category_id) REFERENCES categories(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_envelope_assignments_month ON envelope_assignments(user_id, category_id, month);
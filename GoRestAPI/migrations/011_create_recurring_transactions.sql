@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS recurring_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    category_id INTEGER NOT NULL,
+    account_id INTEGER,
+    amount REAL NOT NULL,
+    description TEXT,
+    frequency TEXT NOT NULL,
+    start_date DATETIME NOT NULL,
+    end_date DATETIME,
+    active BOOLEAN NOT NULL DEFAULT 1,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(category_id) REFERENCES categories(id),
+    FOREIGN KEY(account_id) REFERENCES accounts(id)
+);
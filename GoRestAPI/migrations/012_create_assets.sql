@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS assets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    created_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_assets_user_id ON assets(user_id);
+CREATE TABLE IF NOT EXISTS asset_valuations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    asset_id INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    value REAL NOT NULL,
+    FOREIGN KEY(asset_id) REFERENCES assets(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_valuations_date ON asset_valuations(asset_id, date);
+CREATE TABLE IF NOT EXISTS net_worth_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    accounts REAL NOT NULL,
+    assets REAL NOT NULL,
+    liabilities REAL NOT NULL,
+    net_worth REAL NOT NULL,
+    created_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_net_worth_snapshots_date ON net_worth_snapshots(user_id, date);
@@ -0,0 +1,10 @@
+ALTER TABLE transactions ADD COLUMN payee TEXT;
+ALTER TABLE users ADD COLUMN anomaly_alerts BOOLEAN NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS anomaly_alerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    created_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_anomaly_alerts_key ON anomaly_alerts(user_id, key);
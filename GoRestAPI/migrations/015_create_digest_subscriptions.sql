@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    frequency TEXT NOT NULL,
+    weekday INTEGER NOT NULL DEFAULT 1,
+    time_of_day TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    sections TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT 1,
+    last_sent_at DATETIME,
+    next_run_at DATETIME NOT NULL,
+    created_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_user_id ON digest_subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_next_run_at ON digest_subscriptions(next_run_at);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS monthly_rollups (
+    user_id INTEGER NOT NULL,
+    month DATETIME NOT NULL,
+    category_id INTEGER NOT NULL,
+    income REAL NOT NULL DEFAULT 0,
+    expense REAL NOT NULL DEFAULT 0,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY(user_id, month, category_id),
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(category_id) REFERENCES categories(id)
+);
+INSERT INTO monthly_rollups (user_id, month, category_id, income, expense, count)
+SELECT user_id, strftime('%Y-%m-01 00:00:00+00:00', date), category_id,
+       SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END),
+       SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END),
+       COUNT(*)
+FROM transactions
+GROUP BY 1, 2, 3;